@@ -76,3 +76,20 @@ func IsExpiredResource(e error) bool {
 	_, ok := e.(*ExpiredResourceError)
 	return ok
 }
+
+type ConflictError struct {
+	value string
+}
+
+func (m *ConflictError) Error() string {
+	return formatMessageByValue("Conflict", m.value)
+}
+
+func Conflict(value string) error {
+	return &ConflictError{value}
+}
+
+func IsConflict(e error) bool {
+	_, ok := e.(*ConflictError)
+	return ok
+}
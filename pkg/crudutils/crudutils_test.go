@@ -19,6 +19,16 @@ func TestErrorTypes(t *testing.T) {
 		}
 	}
 
+	{
+		err := Conflict("version 2")
+		if !IsConflict(err) {
+			t.Fatalf("Error is not Conflict: %v", err)
+		}
+		if err.Error() != "Conflict: version 2" {
+			t.Fatalf("Unexpected error message: %v", err)
+		}
+	}
+
 	{
 		err := NotFound("")
 		if IsNotAuthorized(err) {
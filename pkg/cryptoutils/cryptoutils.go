@@ -56,7 +56,11 @@ func Encrypt(value []byte, passPhrase [32]byte) ([]byte, error) {
 	if err != nil {
 		return nil, err
 	}
-	nonce := make([]byte, aesGCM.NonceSize())
+	// the random nonce is prepended to the sealed value
+	nonce, err := RandomBytes(aesGCM.NonceSize())
+	if err != nil {
+		return nil, err
+	}
 	return aesGCM.Seal(nonce, nonce, value, nil), nil
 }
 
@@ -79,6 +83,9 @@ func Decrypt(value []byte, passPhrase [32]byte) ([]byte, error) {
 		return nil, err
 	}
 	nonceSize := aesGCM.NonceSize()
+	if len(value) < nonceSize+aesGCM.Overhead() {
+		return nil, fmt.Errorf("Encrypted value too short")
+	}
 	nonce, secValue := value[:nonceSize], value[nonceSize:]
 	return aesGCM.Open(nil, nonce, secValue, nil)
 }
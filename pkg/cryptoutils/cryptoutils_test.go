@@ -0,0 +1,58 @@
+package cryptoutils
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncrypt(t *testing.T) {
+	passPhrase := [32]byte{1, 2, 3}
+	value := []byte("Hello World")
+
+	first, err := Encrypt(value, passPhrase)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := Encrypt(value, passPhrase)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.False(t, bytes.Equal(first[:12], second[:12]), "nonces should not be reused")
+	assert.False(t, bytes.Equal(first, second))
+
+	for _, enc := range [][]byte{first, second} {
+		dec, err := Decrypt(enc, passPhrase)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, value, dec)
+	}
+
+	_, err = Decrypt(first, [32]byte{4, 5, 6})
+	assert.NotNil(t, err)
+}
+
+func TestDecryptInvalid(t *testing.T) {
+	passPhrase := [32]byte{1, 2, 3}
+	enc, err := Encrypt([]byte("Hello World"), passPhrase)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, value := range [][]byte{nil, enc[:5], enc[:27]} {
+		_, err := Decrypt(value, passPhrase)
+		assert.NotNil(t, err)
+	}
+	enc[len(enc)-1] ^= 0xff
+	_, err = Decrypt(enc, passPhrase)
+	assert.NotNil(t, err)
+
+	empty, err := Encrypt(nil, passPhrase)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dec, err := Decrypt(empty, passPhrase)
+	assert.Nil(t, err)
+	assert.Empty(t, dec)
+}
@@ -0,0 +1,121 @@
+package httputils
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/sandrolain/go-utilities/pkg/redisutils"
+)
+
+type RateLimitResult struct {
+	Allowed    bool
+	Limit      int64
+	Remaining  int64
+	RetryAfter time.Duration
+	Reset      time.Duration
+}
+
+type RateLimiter interface {
+	Allow(ctx context.Context, key string) (RateLimitResult, error)
+}
+
+type redisLimiter struct {
+	limiter redisutils.Limiter
+	prefix  redisutils.Key
+}
+
+// RedisRateLimiter adapts a redisutils limiter to RateLimitMiddleware,
+// storing the request keys under prefix.
+func RedisRateLimiter(limiter redisutils.Limiter, prefix redisutils.Key) RateLimiter {
+	return &redisLimiter{limiter, prefix}
+}
+
+func (l *redisLimiter) Allow(ctx context.Context, key string) (RateLimitResult, error) {
+	res, err := l.limiter.AllowN(ctx, l.prefix.Append(key), 1)
+	return RateLimitResult(res), err
+}
+
+type RateLimitKeyFunc func(r *http.Request) (string, error)
+
+func ClientIPKey(r *http.Request) (string, error) {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	if host == "" {
+		return "", fmt.Errorf("missing client address")
+	}
+	return "ip:" + host, nil
+}
+
+// BearerSubjectKey limits by the subject returned by parse for the request
+// bearer token, falling back to the client IP for anonymous requests or
+// tokens that do not parse.
+func BearerSubjectKey(parse func(token string) (string, error)) RateLimitKeyFunc {
+	return func(r *http.Request) (string, error) {
+		token, err := GetRequestBearerToken(r)
+		if err == nil {
+			if subject, err := parse(token); err == nil && subject != "" {
+				return "sub:" + subject, nil
+			}
+		}
+		return ClientIPKey(r)
+	}
+}
+
+type RateLimitOptions struct {
+	Limiter RateLimiter
+	// Key defaults to ClientIPKey.
+	Key RateLimitKeyFunc
+	// FailClosed rejects requests with 503 when the limiter fails, instead
+	// of letting them through.
+	FailClosed bool
+}
+
+// RateLimitMiddleware rejects requests over quota with 429 and sets the
+// RateLimit-Limit, RateLimit-Remaining, RateLimit-Reset and Retry-After
+// headers.
+func RateLimitMiddleware(opts RateLimitOptions) func(http.Handler) http.Handler {
+	if opts.Key == nil {
+		opts.Key = ClientIPKey
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key, err := opts.Key(r)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			res, err := opts.Limiter.Allow(r.Context(), key)
+			if err != nil {
+				if opts.FailClosed {
+					http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+					return
+				}
+				next.ServeHTTP(w, r)
+				return
+			}
+			h := w.Header()
+			h.Set("RateLimit-Limit", fmt.Sprint(res.Limit))
+			h.Set("RateLimit-Remaining", fmt.Sprint(res.Remaining))
+			h.Set("RateLimit-Reset", fmt.Sprint(ceilSeconds(res.Reset)))
+			if !res.Allowed {
+				h.Set("Retry-After", fmt.Sprint(ceilSeconds(res.RetryAfter)))
+				http.Error(w, http.StatusText(http.StatusTooManyRequests), http.StatusTooManyRequests)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func ceilSeconds(d time.Duration) int64 {
+	if d <= 0 {
+		return 0
+	}
+	return int64(math.Ceil(d.Seconds()))
+}
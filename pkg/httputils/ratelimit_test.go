@@ -0,0 +1,130 @@
+package httputils
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/sandrolain/go-utilities/pkg/redisutils"
+	"github.com/sandrolain/go-utilities/pkg/testredisutils"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeLimiter struct {
+	keys   []string
+	result RateLimitResult
+	err    error
+}
+
+func (l *fakeLimiter) Allow(ctx context.Context, key string) (RateLimitResult, error) {
+	l.keys = append(l.keys, key)
+	return l.result, l.err
+}
+
+func rateLimitedHandler(opts RateLimitOptions) http.Handler {
+	return RateLimitMiddleware(opts)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+}
+
+func serve(handler http.Handler, remoteAddr string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = remoteAddr
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestRateLimitMiddleware(t *testing.T) {
+	limiter := &fakeLimiter{result: RateLimitResult{Allowed: true, Limit: 10, Remaining: 9, Reset: 1500 * time.Millisecond}}
+	handler := rateLimitedHandler(RateLimitOptions{Limiter: limiter})
+
+	rec := serve(handler, "10.0.0.1:1234")
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	assert.Equal(t, "10", rec.Header().Get("RateLimit-Limit"))
+	assert.Equal(t, "9", rec.Header().Get("RateLimit-Remaining"))
+	assert.Equal(t, "2", rec.Header().Get("RateLimit-Reset"))
+	assert.Empty(t, rec.Header().Get("Retry-After"))
+	assert.Equal(t, []string{"ip:10.0.0.1"}, limiter.keys)
+
+	limiter.result = RateLimitResult{Limit: 10, RetryAfter: 2100 * time.Millisecond, Reset: time.Minute}
+	rec = serve(handler, "10.0.0.1:1234")
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.Equal(t, "3", rec.Header().Get("Retry-After"))
+	assert.Equal(t, "0", rec.Header().Get("RateLimit-Remaining"))
+	assert.Equal(t, "60", rec.Header().Get("RateLimit-Reset"))
+
+	rec = serve(handler, "")
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestRateLimitMiddlewareFailure(t *testing.T) {
+	limiter := &fakeLimiter{err: errors.New("unavailable")}
+
+	rec := serve(rateLimitedHandler(RateLimitOptions{Limiter: limiter}), "10.0.0.1:1234")
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	assert.Empty(t, rec.Header().Get("RateLimit-Limit"))
+
+	rec = serve(rateLimitedHandler(RateLimitOptions{Limiter: limiter, FailClosed: true}), "10.0.0.1:1234")
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+}
+
+func TestRateLimitKeys(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = "10.0.0.1:1234"
+	key, err := ClientIPKey(req)
+	assert.Nil(t, err)
+	assert.Equal(t, "ip:10.0.0.1", key)
+
+	req.RemoteAddr = "10.0.0.2"
+	key, err = ClientIPKey(req)
+	assert.Nil(t, err)
+	assert.Equal(t, "ip:10.0.0.2", key)
+
+	keyFunc := BearerSubjectKey(func(token string) (string, error) {
+		if token == "bad" {
+			return "", errors.New("invalid token")
+		}
+		return "user-" + token, nil
+	})
+	key, err = keyFunc(req)
+	assert.Nil(t, err)
+	assert.Equal(t, "ip:10.0.0.2", key)
+
+	req.Header.Set("Authorization", "Bearer abc")
+	key, err = keyFunc(req)
+	assert.Nil(t, err)
+	assert.Equal(t, "sub:user-abc", key)
+
+	req.Header.Set("Authorization", "Bearer bad")
+	key, err = keyFunc(req)
+	assert.Nil(t, err)
+	assert.Equal(t, "ip:10.0.0.2", key)
+}
+
+func TestRedisRateLimiter(t *testing.T) {
+	redisMock := testredisutils.NewMockServer(t, "password")
+	red, err := redisutils.NewClientWithOptions(redisMock.Addr(), redisutils.WithCredentials("", "password"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler := rateLimitedHandler(RateLimitOptions{
+		Limiter: RedisRateLimiter(redisutils.NewSlidingWindowLimiter(red, 1, time.Minute), redisutils.Key{"testing", "http"}),
+	})
+
+	rec := serve(handler, "10.0.0.1:1234")
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	assert.Equal(t, "1", rec.Header().Get("RateLimit-Limit"))
+	assert.Equal(t, "60", rec.Header().Get("RateLimit-Reset"))
+
+	rec = serve(handler, "10.0.0.1:1234")
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.Equal(t, "60", rec.Header().Get("Retry-After"))
+
+	rec = serve(handler, "10.0.0.2:1234")
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	assert.True(t, redisMock.Exists(redisutils.Key{"testing", "http", "ip:10.0.0.2"}.String()))
+}
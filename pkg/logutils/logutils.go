@@ -19,32 +19,49 @@ type Logger struct {
 }
 
 func Debug(msg string) {
-	logr.Zerolog.Debug().Msg(msg)
+	logger().Debug().Msg(msg)
 }
 
 func Info(msg string) {
-	logr.Zerolog.Info().Msg(msg)
+	logger().Info().Msg(msg)
 }
 
 func Infof(msg string, args ...interface{}) {
-	logr.Zerolog.Info().Msgf(msg, args...)
+	logger().Info().Msgf(msg, args...)
 }
 
 func Warn(msg string) {
-	logr.Zerolog.Warn().Msg(msg)
+	logger().Warn().Msg(msg)
+}
+
+func Warnf(msg string, args ...interface{}) {
+	logger().Warn().Msgf(msg, args...)
 }
 
 func Error(err error, msg string, args ...interface{}) {
-	logr.Zerolog.Error().Err(err).Msgf(msg, args...)
+	logger().Error().Err(err).Msgf(msg, args...)
 }
 
 func Fatalf(msg string, args ...interface{}) {
-	logr.Zerolog.Fatal().Msgf(msg, args...)
+	logger().Fatal().Msgf(msg, args...)
 }
 
 var logr *Logger
 
+// fallback is used by the logging functions before InitLogger is called.
+var fallback = zerolog.New(os.Stderr).With().Timestamp().Logger()
+
+func logger() *zerolog.Logger {
+	if logr == nil {
+		return &fallback
+	}
+	return logr.Zerolog
+}
+
 func Close() error {
+	if logr == nil {
+		return nil
+	}
 	os.Stdout = logr.Stdout
 	os.Stderr = logr.Stderr
 	log.SetOutput(logr.Stderr)
@@ -0,0 +1,159 @@
+package mongoutils
+
+import (
+	"context"
+	"sort"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type Pipeline struct {
+	stages mongo.Pipeline
+}
+
+func NewPipeline() *Pipeline {
+	return &Pipeline{
+		stages: mongo.Pipeline{},
+	}
+}
+
+func (p *Pipeline) Stages() mongo.Pipeline {
+	return p.stages
+}
+
+func (p *Pipeline) Stage(name string, value interface{}) *Pipeline {
+	p.stages = append(p.stages, bson.D{{Key: name, Value: value}})
+	return p
+}
+
+func (p *Pipeline) Match(filter interface{}) *Pipeline {
+	return p.Stage("$match", filter)
+}
+
+func (p *Pipeline) Group(id interface{}, fields bson.D) *Pipeline {
+	group := append(bson.D{{Key: "_id", Value: id}}, fields...)
+	return p.Stage("$group", group)
+}
+
+func (p *Pipeline) Project(fields interface{}) *Pipeline {
+	return p.Stage("$project", fields)
+}
+
+func (p *Pipeline) Lookup(from string, localField string, foreignField string, as string) *Pipeline {
+	return p.Stage("$lookup", bson.D{
+		{Key: "from", Value: from},
+		{Key: "localField", Value: localField},
+		{Key: "foreignField", Value: foreignField},
+		{Key: "as", Value: as},
+	})
+}
+
+func (p *Pipeline) LookupPipeline(from string, let bson.D, pipeline *Pipeline, as string) *Pipeline {
+	return p.Stage("$lookup", bson.D{
+		{Key: "from", Value: from},
+		{Key: "let", Value: let},
+		{Key: "pipeline", Value: pipeline.Stages()},
+		{Key: "as", Value: as},
+	})
+}
+
+func (p *Pipeline) Unwind(path string, preserveNullAndEmpty bool) *Pipeline {
+	return p.Stage("$unwind", bson.D{
+		{Key: "path", Value: path},
+		{Key: "preserveNullAndEmptyArrays", Value: preserveNullAndEmpty},
+	})
+}
+
+func (p *Pipeline) Sort(sort bson.D) *Pipeline {
+	return p.Stage("$sort", sort)
+}
+
+func (p *Pipeline) Skip(n int64) *Pipeline {
+	return p.Stage("$skip", n)
+}
+
+func (p *Pipeline) Limit(n int64) *Pipeline {
+	return p.Stage("$limit", n)
+}
+
+func (p *Pipeline) Count(field string) *Pipeline {
+	return p.Stage("$count", field)
+}
+
+func (p *Pipeline) Facet(facets map[string]*Pipeline) *Pipeline {
+	names := make([]string, 0, len(facets))
+	for name := range facets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	facet := make(bson.D, len(names))
+	for i, name := range names {
+		facet[i] = bson.E{Key: name, Value: facets[name].Stages()}
+	}
+	return p.Stage("$facet", facet)
+}
+
+func (p *Pipeline) Bucket(groupBy interface{}, boundaries bson.A, defaultBucket interface{}, output bson.D) *Pipeline {
+	bucket := bson.D{
+		{Key: "groupBy", Value: groupBy},
+		{Key: "boundaries", Value: boundaries},
+	}
+	if defaultBucket != nil {
+		bucket = append(bucket, bson.E{Key: "default", Value: defaultBucket})
+	}
+	if len(output) > 0 {
+		bucket = append(bucket, bson.E{Key: "output", Value: output})
+	}
+	return p.Stage("$bucket", bucket)
+}
+
+func Sum(expr interface{}) bson.D {
+	return bson.D{{Key: "$sum", Value: expr}}
+}
+
+func Avg(expr interface{}) bson.D {
+	return bson.D{{Key: "$avg", Value: expr}}
+}
+
+func Min(expr interface{}) bson.D {
+	return bson.D{{Key: "$min", Value: expr}}
+}
+
+func Max(expr interface{}) bson.D {
+	return bson.D{{Key: "$max", Value: expr}}
+}
+
+func First(expr interface{}) bson.D {
+	return bson.D{{Key: "$first", Value: expr}}
+}
+
+func Last(expr interface{}) bson.D {
+	return bson.D{{Key: "$last", Value: expr}}
+}
+
+func Push(expr interface{}) bson.D {
+	return bson.D{{Key: "$push", Value: expr}}
+}
+
+func AddToSet(expr interface{}) bson.D {
+	return bson.D{{Key: "$addToSet", Value: expr}}
+}
+
+func Aggregate[T interface{}](c *Client, collection string, pipeline *Pipeline) ([]T, error) {
+	return AggregateCtx[T](context.Background(), c, collection, pipeline)
+}
+
+func AggregateCtx[T interface{}](ctx context.Context, c *Client, collection string, pipeline *Pipeline) ([]T, error) {
+	ctx, cancel := withTimeout(ctx, c.timeout)
+	defer cancel()
+	cursor, err := c.Coll(collection).Aggregate(ctx, c.policy(collection).activePipeline(pipeline.Stages()))
+	if err != nil {
+		return nil, err
+	}
+	res := make([]T, 0)
+	if err := cursor.All(ctx, &res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
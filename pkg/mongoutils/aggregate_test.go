@@ -0,0 +1,51 @@
+package mongoutils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func TestPipeline(t *testing.T) {
+	p := NewPipeline().
+		Match(bson.M{"active": true}).
+		Group("$country", bson.D{{Key: "total", Value: Sum(1)}}).
+		Sort(bson.D{{Key: "total", Value: Descending}}).
+		Limit(3)
+
+	assert.Equal(t, mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"active": true}}},
+		{{Key: "$group", Value: bson.D{{Key: "_id", Value: "$country"}, {Key: "total", Value: bson.D{{Key: "$sum", Value: 1}}}}}},
+		{{Key: "$sort", Value: bson.D{{Key: "total", Value: -1}}}},
+		{{Key: "$limit", Value: int64(3)}},
+	}, p.Stages())
+}
+
+func TestAggregate(t *testing.T) {
+	client := newTestClient(t)
+	docs := []interface{}{
+		TestDoc{ID: "a1", Name: "a", Age: 10},
+		TestDoc{ID: "a2", Name: "a", Age: 20},
+		TestDoc{ID: "b1", Name: "b", Age: 40},
+	}
+	if _, err := client.InsertMany("aggregate", docs); err != nil {
+		t.Fatal(err)
+	}
+
+	type result struct {
+		Name  string  `bson:"_id"`
+		Count int     `bson:"count"`
+		Avg   float64 `bson:"avg"`
+	}
+	p := NewPipeline().
+		Group("$name", bson.D{{Key: "count", Value: Sum(1)}, {Key: "avg", Value: Avg("$age")}}).
+		Sort(bson.D{{Key: "_id", Value: Ascending}})
+
+	res, err := Aggregate[result](client, "aggregate", p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []result{{"a", 2, 15}, {"b", 1, 40}}, res)
+}
@@ -0,0 +1,311 @@
+package mongoutils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const DefaultBulkBatchSize = 1000
+
+var (
+	ErrDuplicateKey       = errors.New("duplicate key")
+	ErrDocumentValidation = errors.New("document failed validation")
+)
+
+const documentValidationFailureCode = 121
+
+type BulkOptions struct {
+	Ordered   bool
+	BatchSize int
+	Interval  time.Duration
+	OnFlush   func(*BulkResult, error)
+	// Actor is stamped in the audit fields like WithActor.
+	Actor string
+}
+
+type BulkFailure struct {
+	Index int
+	Code  int
+	Err   error
+}
+
+type BulkResult struct {
+	InsertedCount int64
+	MatchedCount  int64
+	ModifiedCount int64
+	DeletedCount  int64
+	UpsertedCount int64
+	UpsertedIDs   map[int]interface{}
+	Failures      []BulkFailure
+}
+
+func newBulkResult() *BulkResult {
+	return &BulkResult{
+		UpsertedIDs: map[int]interface{}{},
+		Failures:    []BulkFailure{},
+	}
+}
+
+func (r *BulkResult) merge(o *BulkResult) {
+	r.InsertedCount += o.InsertedCount
+	r.MatchedCount += o.MatchedCount
+	r.ModifiedCount += o.ModifiedCount
+	r.DeletedCount += o.DeletedCount
+	r.UpsertedCount += o.UpsertedCount
+	for i, id := range o.UpsertedIDs {
+		r.UpsertedIDs[i] = id
+	}
+	r.Failures = append(r.Failures, o.Failures...)
+}
+
+type BulkWriter struct {
+	client     *Client
+	collection string
+	opts       BulkOptions
+	mu         sync.Mutex
+	models     []mongo.WriteModel
+	offset     int
+	result     *BulkResult
+	err        error
+	stop       chan struct{}
+	done       chan struct{}
+	closeOnce  sync.Once
+}
+
+// NewBulkWriter accumulates write models and flushes them when BatchSize is
+// reached or every Interval. Results of automatic flushes are passed to
+// OnFlush and also collected until the next explicit Flush. The collection
+// policy is applied to the models queued with the typed methods, not to the
+// ones passed to Add.
+func (c *Client) NewBulkWriter(collection string, opts BulkOptions) *BulkWriter {
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = DefaultBulkBatchSize
+	}
+	w := &BulkWriter{
+		client:     c,
+		collection: collection,
+		opts:       opts,
+		models:     make([]mongo.WriteModel, 0, opts.BatchSize),
+		result:     newBulkResult(),
+	}
+	if opts.Interval > 0 {
+		w.stop = make(chan struct{})
+		w.done = make(chan struct{})
+		go w.tick()
+	}
+	return w
+}
+
+func (w *BulkWriter) tick() {
+	defer close(w.done)
+	ticker := time.NewTicker(w.opts.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-w.stop:
+			return
+		case <-ticker.C:
+			w.mu.Lock()
+			w.flush(context.Background())
+			w.mu.Unlock()
+		}
+	}
+}
+
+func (w *BulkWriter) Insert(doc interface{}) int {
+	if stamped, err := w.policy().stampInsert(w.context(), doc); err == nil {
+		// documents that cannot be encoded fail in the driver
+		doc = stamped
+	}
+	return w.add(mongo.NewInsertOneModel().SetDocument(doc))
+}
+
+func (w *BulkWriter) UpdateOne(filter interface{}, update interface{}) int {
+	return w.add(w.updateOneModel(filter, update, false))
+}
+
+func (w *BulkWriter) UpdateMany(filter interface{}, update interface{}) int {
+	p := w.policy()
+	doc, arrayFilters := updateDocument(w.stampUpdate(p, update, false))
+	model := mongo.NewUpdateManyModel().SetFilter(p.activeFilter(filter)).SetUpdate(doc)
+	if arrayFilters != nil {
+		model.SetArrayFilters(*arrayFilters)
+	}
+	return w.add(model)
+}
+
+func (w *BulkWriter) UpsertOne(filter interface{}, update interface{}) int {
+	return w.add(w.updateOneModel(filter, update, true).SetUpsert(true))
+}
+
+func (w *BulkWriter) ReplaceOne(filter interface{}, doc interface{}) int {
+	p := w.policy()
+	filter = p.activeFilter(filter)
+	if p.audit() {
+		if pipeline, err := p.stampReplace(w.context(), doc); err == nil {
+			return w.add(mongo.NewUpdateOneModel().SetFilter(filter).SetUpdate(pipeline))
+		}
+	}
+	return w.add(mongo.NewReplaceOneModel().SetFilter(filter).SetReplacement(doc))
+}
+
+// DeleteOne marks the document as deleted on collections with a soft delete
+// policy, counting it in ModifiedCount instead of DeletedCount.
+func (w *BulkWriter) DeleteOne(filter interface{}) int {
+	if p := w.policy(); p.softDelete() {
+		update := p.deleteUpdate(w.context()).Document()
+		return w.add(mongo.NewUpdateOneModel().SetFilter(p.activeFilter(filter)).SetUpdate(update))
+	}
+	return w.add(mongo.NewDeleteOneModel().SetFilter(filter))
+}
+
+func (w *BulkWriter) DeleteMany(filter interface{}) int {
+	if p := w.policy(); p.softDelete() {
+		update := p.deleteUpdate(w.context()).Document()
+		return w.add(mongo.NewUpdateManyModel().SetFilter(p.activeFilter(filter)).SetUpdate(update))
+	}
+	return w.add(mongo.NewDeleteManyModel().SetFilter(filter))
+}
+
+func (w *BulkWriter) policy() *CollectionPolicy {
+	return w.client.policy(w.collection)
+}
+
+func (w *BulkWriter) context() context.Context {
+	if w.opts.Actor == "" {
+		return context.Background()
+	}
+	return WithActor(context.Background(), w.opts.Actor)
+}
+
+func (w *BulkWriter) stampUpdate(p *CollectionPolicy, update interface{}, upsert bool) interface{} {
+	if stamped, err := p.stampUpdate(w.context(), update, upsert); err == nil {
+		return stamped
+	}
+	return update
+}
+
+func (w *BulkWriter) updateOneModel(filter interface{}, update interface{}, upsert bool) *mongo.UpdateOneModel {
+	p := w.policy()
+	return updateOneModel(p.activeFilter(filter), w.stampUpdate(p, update, upsert))
+}
+
+// Add queues a raw driver model and returns its index across the writer
+// lifetime, the same index reported by BulkFailure and UpsertedIDs.
+func (w *BulkWriter) Add(model mongo.WriteModel) int {
+	return w.add(model)
+}
+
+func (w *BulkWriter) add(model mongo.WriteModel) int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	index := w.offset + len(w.models)
+	w.models = append(w.models, model)
+	if len(w.models) >= w.opts.BatchSize {
+		w.flush(context.Background())
+	}
+	return index
+}
+
+func (w *BulkWriter) Flush() (*BulkResult, error) {
+	return w.FlushCtx(context.Background())
+}
+
+func (w *BulkWriter) FlushCtx(ctx context.Context) (*BulkResult, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.flush(ctx)
+	res, err := w.result, w.err
+	w.result = newBulkResult()
+	w.err = nil
+	return res, err
+}
+
+// Close stops the interval flushes and flushes the queued models. It can be
+// called more than once, also concurrently.
+func (w *BulkWriter) Close() (*BulkResult, error) {
+	w.closeOnce.Do(func() {
+		if w.stop != nil {
+			close(w.stop)
+			<-w.done
+		}
+	})
+	return w.Flush()
+}
+
+func (w *BulkWriter) flush(ctx context.Context) {
+	if len(w.models) == 0 {
+		return
+	}
+	models, offset := w.models, w.offset
+	w.models = make([]mongo.WriteModel, 0, w.opts.BatchSize)
+	w.offset += len(models)
+
+	ctx, cancel := withTimeout(ctx, w.client.timeout)
+	defer cancel()
+	opts := options.BulkWrite().SetOrdered(w.opts.Ordered)
+	res, err := w.client.Coll(w.collection).BulkWrite(ctx, models, opts)
+
+	batch, err := bulkResult(res, err, offset)
+	w.result.merge(batch)
+	if err != nil && w.err == nil {
+		w.err = err
+	}
+	if w.opts.OnFlush != nil {
+		w.opts.OnFlush(batch, err)
+	}
+}
+
+func bulkResult(res *mongo.BulkWriteResult, err error, offset int) (*BulkResult, error) {
+	batch := newBulkResult()
+	if res != nil {
+		batch.InsertedCount = res.InsertedCount
+		batch.MatchedCount = res.MatchedCount
+		batch.ModifiedCount = res.ModifiedCount
+		batch.DeletedCount = res.DeletedCount
+		batch.UpsertedCount = res.UpsertedCount
+		for i, id := range res.UpsertedIDs {
+			batch.UpsertedIDs[offset+int(i)] = id
+		}
+	}
+	var bwe mongo.BulkWriteException
+	if !errors.As(err, &bwe) {
+		return batch, err
+	}
+	for _, we := range bwe.WriteErrors {
+		batch.Failures = append(batch.Failures, BulkFailure{
+			Index: offset + we.Index,
+			Code:  we.Code,
+			Err:   writeError(we.WriteError),
+		})
+	}
+	if bwe.WriteConcernError != nil {
+		return batch, bwe.WriteConcernError
+	}
+	return batch, nil
+}
+
+func updateOneModel(filter interface{}, update interface{}) *mongo.UpdateOneModel {
+	doc, arrayFilters := updateDocument(update)
+	model := mongo.NewUpdateOneModel().SetFilter(filter).SetUpdate(doc)
+	if arrayFilters != nil {
+		model.SetArrayFilters(*arrayFilters)
+	}
+	return model
+}
+
+func writeError(we mongo.WriteError) error {
+	if we.HasErrorCode(documentValidationFailureCode) {
+		return fmt.Errorf("%w: %v", ErrDocumentValidation, we.Message)
+	}
+	if mongo.IsDuplicateKeyError(we) {
+		return fmt.Errorf("%w: %v", ErrDuplicateKey, we.Message)
+	}
+	return we
+}
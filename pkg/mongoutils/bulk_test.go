@@ -0,0 +1,77 @@
+package mongoutils
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func TestBulkResult(t *testing.T) {
+	err := mongo.BulkWriteException{
+		WriteErrors: []mongo.BulkWriteError{
+			{WriteError: mongo.WriteError{Index: 1, Code: 11000, Message: "E11000 duplicate key error"}},
+			{WriteError: mongo.WriteError{Index: 3, Code: 121, Message: "Document failed validation"}},
+		},
+	}
+	res, resErr := bulkResult(&mongo.BulkWriteResult{InsertedCount: 2, UpsertedIDs: map[int64]interface{}{2: "x"}}, err, 10)
+	if resErr != nil {
+		t.Fatal(resErr)
+	}
+	assert.Equal(t, int64(2), res.InsertedCount)
+	assert.Equal(t, map[int]interface{}{12: "x"}, res.UpsertedIDs)
+	assert.Len(t, res.Failures, 2)
+	assert.Equal(t, 11, res.Failures[0].Index)
+	assert.True(t, errors.Is(res.Failures[0].Err, ErrDuplicateKey))
+	assert.Equal(t, 13, res.Failures[1].Index)
+	assert.True(t, errors.Is(res.Failures[1].Err, ErrDocumentValidation))
+}
+
+func TestBulkWriter(t *testing.T) {
+	client := newTestClient(t)
+	flushes := 0
+	w := client.NewBulkWriter("bulk", BulkOptions{
+		BatchSize: 2,
+		OnFlush: func(res *BulkResult, err error) {
+			flushes++
+		},
+	})
+
+	w.Insert(TestDoc{ID: "b1", Name: "one"})
+	dup := w.Insert(TestDoc{ID: "b1", Name: "dup"})
+	w.UpsertOne(bson.M{"_id": "b2"}, bson.M{"name": "two"})
+	w.DeleteOne(bson.M{"_id": "b1"})
+
+	res, err := w.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 2, flushes)
+	assert.Equal(t, int64(1), res.InsertedCount)
+	assert.Equal(t, int64(1), res.UpsertedCount)
+	assert.Equal(t, int64(1), res.DeletedCount)
+	assert.Len(t, res.Failures, 1)
+	assert.Equal(t, dup, res.Failures[0].Index)
+	assert.True(t, errors.Is(res.Failures[0].Err, ErrDuplicateKey))
+}
+
+func TestBulkWriterClose(t *testing.T) {
+	w := (&Client{}).NewBulkWriter("bulk", BulkOptions{Interval: time.Millisecond})
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			res, err := w.Close()
+			assert.Nil(t, err)
+			assert.Empty(t, res.Failures)
+		}()
+	}
+	wg.Wait()
+	_, err := w.Close()
+	assert.Nil(t, err)
+}
@@ -0,0 +1,272 @@
+package mongoutils
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/sandrolain/go-utilities/pkg/crudutils"
+	"github.com/sandrolain/go-utilities/pkg/cryptoutils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/gridfs"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	sha256MetadataField    = "sha256"
+	encryptedMetadataField = "encrypted"
+)
+
+var ErrFileHashMismatch = errors.New("file content does not match its SHA-256 hash")
+
+type BucketOptions struct {
+	Name       string
+	ChunkSize  int32
+	PassPhrase *[32]byte
+}
+
+type FileInfo struct {
+	ID         primitive.ObjectID `bson:"_id"`
+	Name       string             `bson:"filename"`
+	Length     int64              `bson:"length"`
+	ChunkSize  int32              `bson:"chunkSize"`
+	UploadDate time.Time          `bson:"uploadDate"`
+	Metadata   bson.M             `bson:"metadata"`
+}
+
+func (f *FileInfo) Sha256() string {
+	hash, _ := f.Metadata[sha256MetadataField].(string)
+	return hash
+}
+
+func (f *FileInfo) Encrypted() bool {
+	encrypted, _ := f.Metadata[encryptedMetadataField].(bool)
+	return encrypted
+}
+
+// Bucket stores files in GridFS. Files are hashed with SHA-256 on upload and
+// verified on full downloads; with a PassPhrase they are also encrypted with
+// cryptoutils.Encrypt, which needs the whole content in memory.
+type Bucket struct {
+	client *Client
+	bucket *gridfs.Bucket
+	opts   BucketOptions
+}
+
+func (c *Client) Bucket(opts BucketOptions) (*Bucket, error) {
+	bucketOpts := options.GridFSBucket()
+	if opts.Name != "" {
+		bucketOpts.SetName(opts.Name)
+	}
+	if opts.ChunkSize > 0 {
+		bucketOpts.SetChunkSizeBytes(opts.ChunkSize)
+	}
+	bucket, err := gridfs.NewBucket(c.db, bucketOpts)
+	if err != nil {
+		return nil, err
+	}
+	return &Bucket{
+		client: c,
+		bucket: bucket,
+		opts:   opts,
+	}, nil
+}
+
+func (b *Bucket) Upload(name string, source io.Reader, metadata bson.M) (primitive.ObjectID, error) {
+	meta := bson.M{}
+	for k, v := range metadata {
+		meta[k] = v
+	}
+
+	if b.opts.PassPhrase != nil {
+		content, err := io.ReadAll(source)
+		if err != nil {
+			return primitive.NilObjectID, err
+		}
+		enc, err := cryptoutils.Encrypt(content, *b.opts.PassPhrase)
+		if err != nil {
+			return primitive.NilObjectID, err
+		}
+		meta[sha256MetadataField] = hex.EncodeToString(cryptoutils.Sha256Hash(content))
+		meta[encryptedMetadataField] = true
+		return b.bucket.UploadFromStream(name, bytes.NewReader(enc), options.GridFSUpload().SetMetadata(meta))
+	}
+
+	hash := sha256.New()
+	id, err := b.bucket.UploadFromStream(name, io.TeeReader(source, hash), options.GridFSUpload().SetMetadata(meta))
+	if err != nil {
+		return id, err
+	}
+	ctx, cancel := createContext(b.client.timeout)
+	defer cancel()
+	_, err = b.bucket.GetFilesCollection().UpdateByID(ctx, id, bson.M{"$set": bson.M{
+		"metadata." + sha256MetadataField: hex.EncodeToString(hash.Sum(nil)),
+	}})
+	if err != nil {
+		// a file without its hash could not be verified, so it is not kept
+		if delErr := b.Delete(id); delErr != nil {
+			return primitive.NilObjectID, fmt.Errorf("%w, and the file %v could not be removed: %v", err, id.Hex(), delErr)
+		}
+		return primitive.NilObjectID, err
+	}
+	return id, nil
+}
+
+func (b *Bucket) UploadBytes(name string, content []byte, metadata bson.M) (primitive.ObjectID, error) {
+	return b.Upload(name, bytes.NewReader(content), metadata)
+}
+
+func (b *Bucket) Info(id primitive.ObjectID) (*FileInfo, error) {
+	files, err := b.Find(bson.M{"_id": id})
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, crudutils.NotFound(id.Hex())
+	}
+	return &files[0], nil
+}
+
+// Download writes the file content to w and verifies it against the stored
+// hash. Unencrypted files are streamed, so on mismatch w has already received
+// the data and ErrFileHashMismatch is returned at the end.
+func (b *Bucket) Download(id primitive.ObjectID, w io.Writer) error {
+	info, err := b.Info(id)
+	if err != nil {
+		return err
+	}
+	if info.Encrypted() {
+		content, err := b.decrypted(info)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(content)
+		return err
+	}
+
+	hash := sha256.New()
+	if _, err := b.bucket.DownloadToStream(id, io.MultiWriter(w, hash)); err != nil {
+		return err
+	}
+	if expected := info.Sha256(); expected != "" && expected != hex.EncodeToString(hash.Sum(nil)) {
+		return ErrFileHashMismatch
+	}
+	return nil
+}
+
+func (b *Bucket) DownloadBytes(id primitive.ObjectID) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := b.Download(id, &buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// DownloadRange writes length bytes starting at offset. Only encrypted files
+// are verified, since they have to be fully decrypted anyway.
+func (b *Bucket) DownloadRange(id primitive.ObjectID, offset int64, length int64, w io.Writer) error {
+	if offset < 0 || length < 0 {
+		return crudutils.InvalidValue(fmt.Sprintf("range %v+%v", offset, length))
+	}
+	info, err := b.Info(id)
+	if err != nil {
+		return err
+	}
+	if info.Encrypted() {
+		content, err := b.decrypted(info)
+		if err != nil {
+			return err
+		}
+		if offset > int64(len(content)) {
+			return nil
+		}
+		end := offset + length
+		if end > int64(len(content)) {
+			end = int64(len(content))
+		}
+		_, err = w.Write(content[offset:end])
+		return err
+	}
+
+	stream, err := b.bucket.OpenDownloadStream(id)
+	if err != nil {
+		return err
+	}
+	defer stream.Close()
+	if _, err := stream.Skip(offset); err != nil {
+		return err
+	}
+	_, err = io.CopyN(w, stream, length)
+	if err == io.EOF {
+		return nil
+	}
+	return err
+}
+
+func (b *Bucket) decrypted(info *FileInfo) ([]byte, error) {
+	if b.opts.PassPhrase == nil {
+		return nil, fmt.Errorf("file %v is encrypted and the bucket has no pass phrase", info.ID.Hex())
+	}
+	var buf bytes.Buffer
+	if _, err := b.bucket.DownloadToStream(info.ID, &buf); err != nil {
+		return nil, err
+	}
+	content, err := cryptoutils.Decrypt(buf.Bytes(), *b.opts.PassPhrase)
+	if err != nil {
+		return nil, err
+	}
+	hash, err := hex.DecodeString(info.Sha256())
+	if err != nil || !cryptoutils.Sha256Compare(content, hash) {
+		return nil, ErrFileHashMismatch
+	}
+	return content, nil
+}
+
+func (b *Bucket) Find(filter interface{}) ([]FileInfo, error) {
+	return b.FindCtx(context.Background(), filter)
+}
+
+func (b *Bucket) FindCtx(ctx context.Context, filter interface{}) ([]FileInfo, error) {
+	ctx, cancel := withTimeout(ctx, b.client.timeout)
+	defer cancel()
+	if filter == nil {
+		filter = bson.M{}
+	}
+	cursor, err := b.bucket.FindContext(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]FileInfo, 0)
+	if err := cursor.All(ctx, &res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func (b *Bucket) FindByMetadata(field string, value interface{}) ([]FileInfo, error) {
+	return b.Find(bson.M{"metadata." + field: value})
+}
+
+func (b *Bucket) List() ([]FileInfo, error) {
+	return b.Find(bson.M{})
+}
+
+func (b *Bucket) Delete(id primitive.ObjectID) error {
+	return b.DeleteCtx(context.Background(), id)
+}
+
+func (b *Bucket) DeleteCtx(ctx context.Context, id primitive.ObjectID) error {
+	ctx, cancel := withTimeout(ctx, b.client.timeout)
+	defer cancel()
+	err := b.bucket.DeleteContext(ctx, id)
+	if err == gridfs.ErrFileNotFound {
+		return crudutils.NotFound(id.Hex())
+	}
+	return err
+}
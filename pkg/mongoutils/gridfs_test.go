@@ -0,0 +1,62 @@
+package mongoutils
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/sandrolain/go-utilities/pkg/crudutils"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestBucket(t *testing.T) {
+	client := newTestClient(t)
+	content := []byte("hello gridfs content")
+
+	var passPhrase [32]byte
+	copy(passPhrase[:], "01234567890123456789012345678901")
+	buckets := []BucketOptions{
+		{Name: "plain", ChunkSize: 4},
+		{Name: "secret", ChunkSize: 4, PassPhrase: &passPhrase},
+	}
+
+	for _, opts := range buckets {
+		bucket, err := client.Bucket(opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		id, err := bucket.UploadBytes("hello.txt", content, bson.M{"owner": "me"})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		res, err := bucket.DownloadBytes(id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, content, res)
+
+		var part bytes.Buffer
+		if err := bucket.DownloadRange(id, 6, 6, &part); err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, "gridfs", part.String())
+
+		files, err := bucket.FindByMetadata("owner", "me")
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Len(t, files, 1)
+		assert.Equal(t, opts.PassPhrase != nil, files[0].Encrypted())
+		assert.NotEmpty(t, files[0].Sha256())
+
+		if err := bucket.Delete(id); err != nil {
+			t.Fatal(err)
+		}
+		err = bucket.Delete(id)
+		if !crudutils.IsNotFound(err) {
+			t.Fatalf("Error is not NotFound: %v", err)
+		}
+	}
+}
@@ -0,0 +1,290 @@
+package mongoutils
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	Ascending   = 1
+	Descending  = -1
+	Text        = "text"
+	Geo2dsphere = "2dsphere"
+	Hashed      = "hashed"
+)
+
+const idIndexName = "_id_"
+
+type IndexSpec struct {
+	Name               string
+	Keys               bson.D
+	Unique             bool
+	Sparse             bool
+	ExpireAfterSeconds *int32
+	PartialFilter      interface{}
+	Collation          *options.Collation
+}
+
+func (s *IndexSpec) IndexName() string {
+	if s.Name != "" {
+		return s.Name
+	}
+	parts := make([]string, len(s.Keys))
+	for i, k := range s.Keys {
+		parts[i] = fmt.Sprintf("%v_%v", k.Key, k.Value)
+	}
+	return strings.Join(parts, "_")
+}
+
+func (s *IndexSpec) Model() mongo.IndexModel {
+	opts := options.Index().SetName(s.IndexName())
+	if s.Unique {
+		opts.SetUnique(true)
+	}
+	if s.Sparse {
+		opts.SetSparse(true)
+	}
+	if s.ExpireAfterSeconds != nil {
+		opts.SetExpireAfterSeconds(*s.ExpireAfterSeconds)
+	}
+	if s.PartialFilter != nil {
+		opts.SetPartialFilterExpression(s.PartialFilter)
+	}
+	if s.Collation != nil {
+		opts.SetCollation(s.Collation)
+	}
+	return mongo.IndexModel{
+		Keys:    s.Keys,
+		Options: opts,
+	}
+}
+
+func (s *IndexSpec) hasTextKey() bool {
+	for _, k := range s.Keys {
+		if k.Value == Text {
+			return true
+		}
+	}
+	return false
+}
+
+type ReconcileOptions struct {
+	DropUnexpected  bool
+	RecreateDrifted bool
+	DryRun          bool
+}
+
+type IndexDrift struct {
+	Name   string
+	Fields []string
+}
+
+type IndexReport struct {
+	Created    []string
+	Dropped    []string
+	Unexpected []string
+	Drifted    []IndexDrift
+}
+
+func (r *IndexReport) InSync() bool {
+	return len(r.Created) == 0 && len(r.Dropped) == 0 && len(r.Unexpected) == 0 && len(r.Drifted) == 0
+}
+
+func (c *Client) ReconcileIndexes(collection string, specs []IndexSpec, opts ReconcileOptions) (*IndexReport, error) {
+	return c.ReconcileIndexesCtx(context.Background(), collection, specs, opts)
+}
+
+func (c *Client) ReconcileIndexesCtx(ctx context.Context, collection string, specs []IndexSpec, opts ReconcileOptions) (*IndexReport, error) {
+	ctx, cancel := withTimeout(ctx, c.timeout)
+	defer cancel()
+	indexes := c.Coll(collection).Indexes()
+
+	cursor, err := indexes.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	docs := []bson.D{}
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+	existing := make([]bson.M, len(docs))
+	byName := make(map[string]bson.M, len(docs))
+	for i, doc := range docs {
+		existing[i] = doc.Map()
+		if name, ok := existing[i]["name"].(string); ok {
+			byName[name] = existing[i]
+		}
+	}
+
+	report := &IndexReport{}
+	create := []mongo.IndexModel{}
+	expected := make(map[string]bool, len(specs))
+	for i := range specs {
+		spec := &specs[i]
+		name := spec.IndexName()
+		expected[name] = true
+		current, ok := byName[name]
+		if !ok {
+			report.Created = append(report.Created, name)
+			create = append(create, spec.Model())
+			continue
+		}
+		fields := indexDrift(spec, current)
+		if len(fields) == 0 {
+			continue
+		}
+		report.Drifted = append(report.Drifted, IndexDrift{Name: name, Fields: fields})
+		if opts.RecreateDrifted {
+			if !opts.DryRun {
+				if _, err := indexes.DropOne(ctx, name); err != nil {
+					return report, err
+				}
+			}
+			create = append(create, spec.Model())
+		}
+	}
+
+	for _, idx := range existing {
+		name, _ := idx["name"].(string)
+		if name == idIndexName || expected[name] {
+			continue
+		}
+		if !opts.DropUnexpected {
+			report.Unexpected = append(report.Unexpected, name)
+			continue
+		}
+		if !opts.DryRun {
+			if _, err := indexes.DropOne(ctx, name); err != nil {
+				return report, err
+			}
+		}
+		report.Dropped = append(report.Dropped, name)
+	}
+
+	if len(create) > 0 && !opts.DryRun {
+		if _, err := indexes.CreateMany(ctx, create); err != nil {
+			return report, err
+		}
+	}
+	return report, nil
+}
+
+func indexDrift(spec *IndexSpec, current bson.M) []string {
+	fields := []string{}
+	if !spec.hasTextKey() && !sameKeys(spec.Keys, current["key"]) {
+		fields = append(fields, "key")
+	}
+	if spec.Unique != isTrue(current["unique"]) {
+		fields = append(fields, "unique")
+	}
+	if spec.Sparse != isTrue(current["sparse"]) {
+		fields = append(fields, "sparse")
+	}
+	var expire interface{}
+	if spec.ExpireAfterSeconds != nil {
+		expire = *spec.ExpireAfterSeconds
+	}
+	if !sameValue(expire, current["expireAfterSeconds"]) {
+		fields = append(fields, "expireAfterSeconds")
+	}
+	if !sameValue(spec.PartialFilter, current["partialFilterExpression"]) {
+		fields = append(fields, "partialFilterExpression")
+	}
+	if !sameCollation(spec.Collation, current["collation"]) {
+		fields = append(fields, "collation")
+	}
+	return fields
+}
+
+func sameKeys(keys bson.D, current interface{}) bool {
+	doc, ok := current.(primitive.D)
+	if !ok || len(doc) != len(keys) {
+		return false
+	}
+	for i, k := range keys {
+		if k.Key != doc[i].Key || !sameValue(k.Value, doc[i].Value) {
+			return false
+		}
+	}
+	return true
+}
+
+// sameCollation compares only the fields set in collation, since the server
+// reports every default, but an index without collation must have none.
+func sameCollation(collation *options.Collation, current interface{}) bool {
+	if collation == nil || current == nil {
+		return collation == nil && current == nil
+	}
+	expected := bson.M{}
+	if err := bson.Unmarshal(collation.ToDocument(), &expected); err != nil {
+		return false
+	}
+	doc, ok := normalizeValue(current).(map[string]interface{})
+	if !ok {
+		return false
+	}
+	for k, v := range expected {
+		if !reflect.DeepEqual(normalizeValue(v), doc[k]) {
+			return false
+		}
+	}
+	return true
+}
+
+func sameValue(expected interface{}, current interface{}) bool {
+	if expected == nil || current == nil {
+		return expected == nil && current == nil
+	}
+	var wrapped struct {
+		V interface{} `bson:"v"`
+	}
+	b, err := bson.Marshal(bson.M{"v": expected})
+	if err != nil || bson.Unmarshal(b, &wrapped) != nil {
+		return false
+	}
+	return reflect.DeepEqual(normalizeValue(wrapped.V), normalizeValue(current))
+}
+
+func isTrue(v interface{}) bool {
+	b, ok := v.(bool)
+	return ok && b
+}
+
+// normalizeValue makes decoded BSON comparable regardless of the numeric
+// width chosen by the server and of the document key order.
+func normalizeValue(v interface{}) interface{} {
+	switch val := v.(type) {
+	case int32:
+		return float64(val)
+	case int64:
+		return float64(val)
+	case int:
+		return float64(val)
+	case primitive.D:
+		res := make(map[string]interface{}, len(val))
+		for _, e := range val {
+			res[e.Key] = normalizeValue(e.Value)
+		}
+		return res
+	case primitive.M:
+		res := make(map[string]interface{}, len(val))
+		for k, e := range val {
+			res[k] = normalizeValue(e)
+		}
+		return res
+	case primitive.A:
+		res := make([]interface{}, len(val))
+		for i, e := range val {
+			res[i] = normalizeValue(e)
+		}
+		return res
+	}
+	return v
+}
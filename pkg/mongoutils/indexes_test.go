@@ -0,0 +1,86 @@
+package mongoutils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func TestIndexDrift(t *testing.T) {
+	spec := IndexSpec{
+		Keys:          bson.D{{Key: "email", Value: Ascending}, {Key: "createdAt", Value: Descending}},
+		Unique:        true,
+		PartialFilter: bson.D{{Key: "active", Value: true}},
+	}
+	assert.Equal(t, "email_1_createdAt_-1", spec.IndexName())
+
+	current := bson.M{
+		"name":                    "email_1_createdAt_-1",
+		"key":                     primitive.D{{Key: "email", Value: int32(1)}, {Key: "createdAt", Value: float64(-1)}},
+		"unique":                  true,
+		"partialFilterExpression": primitive.D{{Key: "active", Value: true}},
+	}
+	assert.Empty(t, indexDrift(&spec, current))
+
+	current["unique"] = false
+	current["sparse"] = true
+	current["key"] = primitive.D{{Key: "createdAt", Value: int32(-1)}, {Key: "email", Value: int32(1)}}
+	assert.Equal(t, []string{"key", "unique", "sparse"}, indexDrift(&spec, current))
+
+	spec = IndexSpec{Keys: bson.D{{Key: "name", Value: Ascending}}}
+	current = bson.M{
+		"name":      "name_1",
+		"key":       primitive.D{{Key: "name", Value: int32(1)}},
+		"collation": primitive.D{{Key: "locale", Value: "en"}, {Key: "strength", Value: int32(2)}},
+	}
+	assert.Equal(t, []string{"collation"}, indexDrift(&spec, current))
+
+	spec.Collation = &options.Collation{Locale: "en", Strength: 2}
+	assert.Empty(t, indexDrift(&spec, current))
+
+	delete(current, "collation")
+	assert.Equal(t, []string{"collation"}, indexDrift(&spec, current))
+}
+
+func TestReconcileIndexes(t *testing.T) {
+	client := newTestClient(t)
+	if _, err := client.AssertIndex("indexes", "legacy"); err != nil {
+		t.Fatal(err)
+	}
+
+	ttl := int32(3600)
+	specs := []IndexSpec{
+		{Keys: bson.D{{Key: "email", Value: Ascending}}, Unique: true},
+		{Keys: bson.D{{Key: "expiresAt", Value: Ascending}}, ExpireAfterSeconds: &ttl},
+		{Keys: bson.D{{Key: "location", Value: Geo2dsphere}}},
+	}
+
+	report, err := client.ReconcileIndexes("indexes", specs, ReconcileOptions{DryRun: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Len(t, report.Created, 3)
+	assert.Equal(t, []string{"legacy_1"}, report.Unexpected)
+
+	report, err = client.ReconcileIndexes("indexes", specs, ReconcileOptions{DropUnexpected: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []string{"legacy_1"}, report.Dropped)
+
+	report, err = client.ReconcileIndexes("indexes", specs, ReconcileOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, report.InSync())
+
+	specs[0].Unique = false
+	report, err = client.ReconcileIndexes("indexes", specs, ReconcileOptions{RecreateDrifted: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []IndexDrift{{Name: "email_1", Fields: []string{"unique"}}}, report.Drifted)
+}
@@ -0,0 +1,304 @@
+package mongoutils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	DefaultMigrationsCollection = "migrations"
+	DefaultMigrationsLockTtl    = 15 * time.Minute
+	migrationsLockID            = "lock"
+)
+
+var (
+	ErrMigrationsLocked   = errors.New("migrations are locked by another runner")
+	ErrMigrationsLockLost = errors.New("migrations lock taken over by another runner")
+)
+
+type MigrationFunc func(ctx context.Context, c *Client) error
+
+type Migration struct {
+	Version     uint64
+	Description string
+	Up          MigrationFunc
+	Down        MigrationFunc
+}
+
+type MigrationRecord struct {
+	Version     uint64    `bson:"_id"`
+	Description string    `bson:"description"`
+	AppliedAt   time.Time `bson:"appliedAt"`
+}
+
+type MigrationStatus struct {
+	Version     uint64
+	Description string
+	Applied     bool
+	AppliedAt   time.Time
+}
+
+type Migrator struct {
+	client     *Client
+	collection string
+	migrations []Migration
+	LockTtl    time.Duration
+}
+
+func NewMigrator(client *Client, collection string, migrations []Migration) (*Migrator, error) {
+	if client == nil {
+		return nil, fmt.Errorf("empty MongoDB client")
+	}
+	if collection == "" {
+		collection = DefaultMigrationsCollection
+	}
+	sorted := make([]Migration, len(migrations))
+	copy(sorted, migrations)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Version < sorted[j].Version
+	})
+	for i, m := range sorted {
+		if m.Version == 0 {
+			return nil, fmt.Errorf("migration version must be greater than 0")
+		}
+		if m.Up == nil {
+			return nil, fmt.Errorf("migration %v has no up step", m.Version)
+		}
+		if i > 0 && sorted[i-1].Version == m.Version {
+			return nil, fmt.Errorf("duplicated migration version %v", m.Version)
+		}
+	}
+	return &Migrator{
+		client:     client,
+		collection: collection,
+		migrations: sorted,
+		LockTtl:    DefaultMigrationsLockTtl,
+	}, nil
+}
+
+func (m *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]MigrationStatus, len(m.migrations))
+	for i, mig := range m.migrations {
+		res[i] = MigrationStatus{
+			Version:     mig.Version,
+			Description: mig.Description,
+		}
+		if rec, ok := applied[mig.Version]; ok {
+			res[i].Applied = true
+			res[i].AppliedAt = rec.AppliedAt
+		}
+	}
+	return res, nil
+}
+
+// Up applies the pending migrations up to target included, or all of them when
+// target is 0. With dryRun the versions are reported but nothing is executed.
+func (m *Migrator) Up(ctx context.Context, target uint64, dryRun bool) ([]uint64, error) {
+	return m.run(ctx, dryRun, func(ctx context.Context, applied map[uint64]MigrationRecord, checkLock func() error) ([]uint64, error) {
+		done := []uint64{}
+		for _, mig := range m.migrations {
+			if target > 0 && mig.Version > target {
+				break
+			}
+			if _, ok := applied[mig.Version]; ok {
+				continue
+			}
+			if !dryRun {
+				if err := mig.Up(ctx, m.client); err != nil {
+					return done, fmt.Errorf("migration %v up: %w", mig.Version, err)
+				}
+				if err := checkLock(); err != nil {
+					return done, err
+				}
+				rec := MigrationRecord{
+					Version:     mig.Version,
+					Description: mig.Description,
+					AppliedAt:   time.Now().UTC(),
+				}
+				if _, err := m.client.InsertOneCtx(ctx, m.collection, rec); err != nil {
+					return done, err
+				}
+			}
+			done = append(done, mig.Version)
+		}
+		return done, nil
+	})
+}
+
+// Down reverts the applied migrations with a version greater than target,
+// newest first. With dryRun the versions are reported but nothing is executed.
+func (m *Migrator) Down(ctx context.Context, target uint64, dryRun bool) ([]uint64, error) {
+	return m.run(ctx, dryRun, func(ctx context.Context, applied map[uint64]MigrationRecord, checkLock func() error) ([]uint64, error) {
+		done := []uint64{}
+		for i := len(m.migrations) - 1; i >= 0; i-- {
+			mig := m.migrations[i]
+			if mig.Version <= target {
+				break
+			}
+			if _, ok := applied[mig.Version]; !ok {
+				continue
+			}
+			if mig.Down == nil {
+				return done, fmt.Errorf("migration %v has no down step", mig.Version)
+			}
+			if !dryRun {
+				if err := mig.Down(ctx, m.client); err != nil {
+					return done, fmt.Errorf("migration %v down: %w", mig.Version, err)
+				}
+				if err := checkLock(); err != nil {
+					return done, err
+				}
+				if _, err := m.client.DeleteOneByIdCtx(ctx, m.collection, mig.Version); err != nil {
+					return done, err
+				}
+			}
+			done = append(done, mig.Version)
+		}
+		return done, nil
+	})
+}
+
+// run executes fn holding the migrations lock, which is refreshed in the
+// background every third of LockTtl. Losing the lock cancels the context of
+// fn, and checkLock verifies it is still held before each migration is
+// recorded.
+func (m *Migrator) run(ctx context.Context, dryRun bool, fn func(ctx context.Context, applied map[uint64]MigrationRecord, checkLock func() error) ([]uint64, error)) (done []uint64, err error) {
+	checkLock := func() error { return nil }
+	if !dryRun {
+		var owner string
+		if owner, err = m.lock(ctx); err != nil {
+			return nil, err
+		}
+		defer func() {
+			if unlockErr := m.unlock(owner); err == nil {
+				err = unlockErr
+			}
+		}()
+		var stop func() error
+		ctx, stop = m.heartbeat(ctx, owner)
+		defer func() {
+			if lostErr := stop(); lostErr != nil {
+				err = lostErr
+			}
+		}()
+		checkLock = func() error {
+			return m.refresh(ctx, owner)
+		}
+	}
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return fn(ctx, applied, checkLock)
+}
+
+// heartbeat refreshes the lock until stop is called, which returns
+// ErrMigrationsLockLost if the lock was taken over in the meantime.
+func (m *Migrator) heartbeat(ctx context.Context, owner string) (context.Context, func() error) {
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	var lost error
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ticker := time.NewTicker(m.lockTtl() / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				// other failures are retried at the next tick, while the
+				// lock is still valid
+				if err := m.refresh(ctx, owner); err == ErrMigrationsLockLost {
+					lost = err
+					cancel()
+					return
+				}
+			}
+		}
+	}()
+	return ctx, func() error {
+		close(done)
+		wg.Wait()
+		cancel()
+		return lost
+	}
+}
+
+func (m *Migrator) applied(ctx context.Context) (map[uint64]MigrationRecord, error) {
+	records := []MigrationRecord{}
+	if err := m.client.FindManyCtx(ctx, m.collection, bson.M{}, bson.M{"_id": 1}, 0, &records); err != nil {
+		return nil, err
+	}
+	res := make(map[uint64]MigrationRecord, len(records))
+	for _, rec := range records {
+		res[rec.Version] = rec
+	}
+	return res, nil
+}
+
+func (m *Migrator) lockTtl() time.Duration {
+	if m.LockTtl <= 0 {
+		return DefaultMigrationsLockTtl
+	}
+	return m.LockTtl
+}
+
+func (m *Migrator) lockCollection() string {
+	return m.collection + "_lock"
+}
+
+// lock upserts the lock document only when it is missing or stale, so a fresh
+// lock held by another runner makes the upsert fail with a duplicate key.
+func (m *Migrator) lock(ctx context.Context) (string, error) {
+	ctx, cancel := withTimeout(ctx, m.client.timeout)
+	defer cancel()
+	owner := primitive.NewObjectID().Hex()
+	now := time.Now().UTC()
+	filter := bson.M{
+		"_id":      migrationsLockID,
+		"lockedAt": bson.M{"$lt": now.Add(-m.lockTtl())},
+	}
+	update := bson.M{"$set": bson.M{"owner": owner, "lockedAt": now}}
+	opts := options.Update().SetUpsert(true)
+	_, err := m.client.Coll(m.lockCollection()).UpdateOne(ctx, filter, update, opts)
+	if mongo.IsDuplicateKeyError(err) {
+		return "", ErrMigrationsLocked
+	}
+	return owner, err
+}
+
+func (m *Migrator) refresh(ctx context.Context, owner string) error {
+	ctx, cancel := withTimeout(ctx, m.client.timeout)
+	defer cancel()
+	filter := bson.M{"_id": migrationsLockID, "owner": owner}
+	update := bson.M{"$set": bson.M{"lockedAt": time.Now().UTC()}}
+	res, err := m.client.Coll(m.lockCollection()).UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrMigrationsLockLost
+	}
+	return nil
+}
+
+func (m *Migrator) unlock(owner string) error {
+	_, err := m.client.DeleteOne(m.lockCollection(), bson.M{"_id": migrationsLockID, "owner": owner})
+	return err
+}
@@ -0,0 +1,137 @@
+package mongoutils
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestMigrator(t *testing.T) {
+	client := newTestClient(t)
+	ctx := context.Background()
+
+	migrations := []Migration{
+		{
+			Version:     2,
+			Description: "add email index",
+			Up: func(ctx context.Context, c *Client) error {
+				_, err := c.AssertUniqueIndexCtx(ctx, "migrated", "email")
+				return err
+			},
+			Down: func(ctx context.Context, c *Client) error {
+				_, err := c.Coll("migrated").Indexes().DropOne(ctx, "email_1")
+				return err
+			},
+		},
+		{
+			Version:     1,
+			Description: "seed admin",
+			Up: func(ctx context.Context, c *Client) error {
+				_, err := c.InsertOneCtx(ctx, "migrated", bson.M{"_id": "admin", "email": "admin@example.com"})
+				return err
+			},
+			Down: func(ctx context.Context, c *Client) error {
+				_, err := c.DeleteOneByIdCtx(ctx, "migrated", "admin")
+				return err
+			},
+		},
+	}
+
+	m, err := NewMigrator(client, "", migrations)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	done, err := m.Up(ctx, 0, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []uint64{1, 2}, done)
+
+	done, err = m.Up(ctx, 1, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []uint64{1}, done)
+
+	status, err := m.Status(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, status[0].Applied)
+	assert.False(t, status[1].Applied)
+
+	owner, err := m.lock(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = m.Up(ctx, 0, false)
+	assert.ErrorIs(t, err, ErrMigrationsLocked)
+	if err := m.unlock(owner); err != nil {
+		t.Fatal(err)
+	}
+
+	done, err = m.Up(ctx, 0, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []uint64{2}, done)
+
+	done, err = m.Down(ctx, 0, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []uint64{2, 1}, done)
+}
+
+func TestMigratorLockHeartbeat(t *testing.T) {
+	client := newTestClient(t)
+	ctx := context.Background()
+
+	var other *Migrator
+	migrations := []Migration{
+		{
+			Version: 1,
+			Up: func(ctx context.Context, c *Client) error {
+				// the lock outlives its TTL while the migration runs
+				time.Sleep(time.Second)
+				_, err := other.Up(ctx, 0, false)
+				assert.ErrorIs(t, err, ErrMigrationsLocked)
+				return nil
+			},
+		},
+		{
+			Version: 2,
+			Up: func(ctx context.Context, c *Client) error {
+				_, err := c.Coll("heartbeat_migrations_lock").UpdateOne(ctx,
+					bson.M{"_id": migrationsLockID},
+					bson.M{"$set": bson.M{"owner": "other"}})
+				return err
+			},
+		},
+	}
+	m, err := NewMigrator(client, "heartbeat_migrations", migrations)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.LockTtl = 300 * time.Millisecond
+	other, err = NewMigrator(client, "heartbeat_migrations", migrations)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other.LockTtl = m.LockTtl
+
+	done, err := m.Up(ctx, 0, false)
+	assert.ErrorIs(t, err, ErrMigrationsLockLost)
+	assert.Equal(t, []uint64{1}, done)
+
+	status, err := m.Status(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, status[0].Applied)
+	assert.False(t, status[1].Applied)
+}
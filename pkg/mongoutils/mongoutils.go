@@ -3,6 +3,7 @@ package mongoutils
 import (
 	"context"
 	"fmt"
+	"sync"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
@@ -12,9 +13,11 @@ import (
 )
 
 type Client struct {
-	client  *mongo.Client
-	db      *mongo.Database
-	timeout time.Duration
+	client     *mongo.Client
+	db         *mongo.Database
+	timeout    time.Duration
+	policiesMu sync.RWMutex
+	policies   map[string]*CollectionPolicy
 }
 
 func (c *Client) Close() {
@@ -28,18 +31,28 @@ func (c *Client) Close() {
 }
 
 func (c *Client) Ping() error {
-	ctx, cancel := createContext(c.timeout)
+	return c.PingCtx(context.Background())
+}
+
+func (c *Client) PingCtx(ctx context.Context) error {
+	ctx, cancel := withTimeout(ctx, c.timeout)
 	defer cancel()
-	if err := c.client.Ping(ctx, readpref.Primary()); err != nil {
-		return err
-	}
-	fmt.Println("Database Ping success")
-	return nil
+	return c.client.Ping(ctx, readpref.Primary())
+}
+
+func (c *Client) Timeout() time.Duration {
+	return c.timeout
 }
 
 func (c *Client) AssertUniqueIndex(collection string, field string) (string, error) {
+	return c.AssertUniqueIndexCtx(context.Background(), collection, field)
+}
+
+func (c *Client) AssertUniqueIndexCtx(ctx context.Context, collection string, field string) (string, error) {
+	ctx, cancel := withTimeout(ctx, c.timeout)
+	defer cancel()
 	return c.Coll(collection).Indexes().CreateOne(
-		context.Background(),
+		ctx,
 		mongo.IndexModel{
 			Keys:    bson.D{{Key: field, Value: 1}},
 			Options: options.Index().SetUnique(true),
@@ -48,8 +61,14 @@ func (c *Client) AssertUniqueIndex(collection string, field string) (string, err
 }
 
 func (c *Client) AssertIndex(collection string, field string) (string, error) {
+	return c.AssertIndexCtx(context.Background(), collection, field)
+}
+
+func (c *Client) AssertIndexCtx(ctx context.Context, collection string, field string) (string, error) {
+	ctx, cancel := withTimeout(ctx, c.timeout)
+	defer cancel()
 	return c.Coll(collection).Indexes().CreateOne(
-		context.Background(),
+		ctx,
 		mongo.IndexModel{
 			Keys: bson.D{{Key: field, Value: 1}},
 		},
@@ -57,8 +76,14 @@ func (c *Client) AssertIndex(collection string, field string) (string, error) {
 }
 
 func (c *Client) AssertTtlIndex(collection string, field string, expireSeconds int32) (string, error) {
+	return c.AssertTtlIndexCtx(context.Background(), collection, field, expireSeconds)
+}
+
+func (c *Client) AssertTtlIndexCtx(ctx context.Context, collection string, field string, expireSeconds int32) (string, error) {
+	ctx, cancel := withTimeout(ctx, c.timeout)
+	defer cancel()
 	return c.Coll(collection).Indexes().CreateOne(
-		context.Background(),
+		ctx,
 		mongo.IndexModel{
 			Keys: bson.D{{Key: field, Value: 1}},
 			Options: &options.IndexOptions{
@@ -73,7 +98,12 @@ func (c *Client) Coll(collection string) *mongo.Collection {
 }
 
 func (c *Client) FindOne(collection string, filter interface{}, v interface{}) (bool, error) {
-	ctx, cancel := createContext(c.timeout)
+	return c.FindOneCtx(context.Background(), collection, filter, v)
+}
+
+func (c *Client) FindOneCtx(ctx context.Context, collection string, filter interface{}, v interface{}) (bool, error) {
+	filter = c.policy(collection).activeFilter(filter)
+	ctx, cancel := withTimeout(ctx, c.timeout)
 	defer cancel()
 	err := c.db.Collection(collection).FindOne(ctx, filter).Decode(v)
 	if err != nil {
@@ -86,17 +116,30 @@ func (c *Client) FindOne(collection string, filter interface{}, v interface{}) (
 }
 
 func (c *Client) FindOneById(collection string, id interface{}, v interface{}) (bool, error) {
+	return c.FindOneByIdCtx(context.Background(), collection, id, v)
+}
+
+func (c *Client) FindOneByIdCtx(ctx context.Context, collection string, id interface{}, v interface{}) (bool, error) {
 	filter := bson.M{"_id": id}
-	return c.FindOne(collection, filter, v)
+	return c.FindOneCtx(ctx, collection, filter, v)
 }
 
 func (c *Client) FindOneByField(collection string, field string, value interface{}, v interface{}) (bool, error) {
+	return c.FindOneByFieldCtx(context.Background(), collection, field, value, v)
+}
+
+func (c *Client) FindOneByFieldCtx(ctx context.Context, collection string, field string, value interface{}, v interface{}) (bool, error) {
 	filter := bson.M{field: value}
-	return c.FindOne(collection, filter, v)
+	return c.FindOneCtx(ctx, collection, filter, v)
 }
 
 func (c *Client) FindMany(collection string, filter interface{}, sort interface{}, limit int64, v interface{}) error {
-	ctx, cancel := createContext(c.timeout)
+	return c.FindManyCtx(context.Background(), collection, filter, sort, limit, v)
+}
+
+func (c *Client) FindManyCtx(ctx context.Context, collection string, filter interface{}, sort interface{}, limit int64, v interface{}) error {
+	filter = c.policy(collection).activeFilter(filter)
+	ctx, cancel := withTimeout(ctx, c.timeout)
 	defer cancel()
 	opts := options.Find()
 	if sort != nil {
@@ -113,102 +156,222 @@ func (c *Client) FindMany(collection string, filter interface{}, sort interface{
 }
 
 func (c *Client) FindManyByField(collection string, field string, value interface{}, limit int64, v interface{}) error {
+	return c.FindManyByFieldCtx(context.Background(), collection, field, value, limit, v)
+}
+
+func (c *Client) FindManyByFieldCtx(ctx context.Context, collection string, field string, value interface{}, limit int64, v interface{}) error {
 	filter := bson.M{field: value}
-	return c.FindMany(collection, filter, nil, limit, v)
+	return c.FindManyCtx(ctx, collection, filter, nil, limit, v)
 }
 
 func (c *Client) InsertOne(collection string, v interface{}) (*mongo.InsertOneResult, error) {
-	ctx, cancel := createContext(c.timeout)
+	return c.InsertOneCtx(context.Background(), collection, v)
+}
+
+func (c *Client) InsertOneCtx(ctx context.Context, collection string, v interface{}) (*mongo.InsertOneResult, error) {
+	v, err := c.policy(collection).stampInsert(ctx, v)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := withTimeout(ctx, c.timeout)
 	defer cancel()
 	return c.db.Collection(collection).InsertOne(ctx, v)
 }
 
 func (c *Client) InsertMany(collection string, v []interface{}) (*mongo.InsertManyResult, error) {
-	ctx, cancel := createContext(c.timeout)
+	return c.InsertManyCtx(context.Background(), collection, v)
+}
+
+func (c *Client) InsertManyCtx(ctx context.Context, collection string, v []interface{}) (*mongo.InsertManyResult, error) {
+	if p := c.policy(collection); p.audit() {
+		docs := make([]interface{}, len(v))
+		for i, doc := range v {
+			stamped, err := p.stampInsert(ctx, doc)
+			if err != nil {
+				return nil, err
+			}
+			docs[i] = stamped
+		}
+		v = docs
+	}
+	ctx, cancel := withTimeout(ctx, c.timeout)
 	defer cancel()
 	return c.db.Collection(collection).InsertMany(ctx, v)
 }
 
 func (c *Client) UpdateOne(collection string, filter interface{}, update interface{}) (*mongo.UpdateResult, error) {
+	return c.UpdateOneCtx(context.Background(), collection, filter, update)
+}
+
+func (c *Client) UpdateOneCtx(ctx context.Context, collection string, filter interface{}, update interface{}) (*mongo.UpdateResult, error) {
+	p := c.policy(collection)
+	filter = p.activeFilter(filter)
+	update, err := p.stampUpdate(ctx, update, false)
+	if err != nil {
+		return nil, err
+	}
+	doc, arrayFilters := updateDocument(update)
 	opts := options.Update()
-	ctx, cancel := createContext(c.timeout)
+	if arrayFilters != nil {
+		opts.SetArrayFilters(*arrayFilters)
+	}
+	ctx, cancel := withTimeout(ctx, c.timeout)
 	defer cancel()
-	return c.db.Collection(collection).UpdateOne(ctx, filter, bson.M{"$set": update}, opts)
+	return c.db.Collection(collection).UpdateOne(ctx, filter, doc, opts)
 }
 
 func (c *Client) UpdateOneByField(collection string, field string, value interface{}, update interface{}) (*mongo.UpdateResult, error) {
+	return c.UpdateOneByFieldCtx(context.Background(), collection, field, value, update)
+}
+
+func (c *Client) UpdateOneByFieldCtx(ctx context.Context, collection string, field string, value interface{}, update interface{}) (*mongo.UpdateResult, error) {
 	filter := bson.M{field: value}
-	return c.UpdateOne(collection, filter, update)
+	return c.UpdateOneCtx(ctx, collection, filter, update)
 }
 
 func (c *Client) UpdateOneById(collection string, id interface{}, update interface{}) (*mongo.UpdateResult, error) {
-	return c.UpdateOne(collection, bson.M{"_id": id}, update)
+	return c.UpdateOneByIdCtx(context.Background(), collection, id, update)
+}
+
+func (c *Client) UpdateOneByIdCtx(ctx context.Context, collection string, id interface{}, update interface{}) (*mongo.UpdateResult, error) {
+	return c.UpdateOneCtx(ctx, collection, bson.M{"_id": id}, update)
 }
 
 func (c *Client) UpsertOne(collection string, filter interface{}, update interface{}) (*mongo.UpdateResult, error) {
+	return c.UpsertOneCtx(context.Background(), collection, filter, update)
+}
+
+func (c *Client) UpsertOneCtx(ctx context.Context, collection string, filter interface{}, update interface{}) (*mongo.UpdateResult, error) {
+	p := c.policy(collection)
+	// a soft deleted match is not revived, the upsert fails on its _id
+	filter = p.activeFilter(filter)
+	update, err := p.stampUpdate(ctx, update, true)
+	if err != nil {
+		return nil, err
+	}
+	doc, arrayFilters := updateDocument(update)
 	opts := options.Update().SetUpsert(true)
-	ctx, cancel := createContext(c.timeout)
+	if arrayFilters != nil {
+		opts.SetArrayFilters(*arrayFilters)
+	}
+	ctx, cancel := withTimeout(ctx, c.timeout)
 	defer cancel()
-	return c.db.Collection(collection).UpdateOne(ctx, filter, bson.M{"$set": update}, opts)
+	return c.db.Collection(collection).UpdateOne(ctx, filter, doc, opts)
 }
 
 func (c *Client) UpsertOneByField(collection string, field string, value interface{}, update interface{}) (*mongo.UpdateResult, error) {
+	return c.UpsertOneByFieldCtx(context.Background(), collection, field, value, update)
+}
+
+func (c *Client) UpsertOneByFieldCtx(ctx context.Context, collection string, field string, value interface{}, update interface{}) (*mongo.UpdateResult, error) {
 	filter := bson.M{field: value}
-	return c.UpsertOne(collection, filter, update)
+	return c.UpsertOneCtx(ctx, collection, filter, update)
 }
 
 func (c *Client) UpsertOneById(collection string, id interface{}, update interface{}) (*mongo.UpdateResult, error) {
-	return c.UpsertOne(collection, bson.M{"_id": id}, update)
+	return c.UpsertOneByIdCtx(context.Background(), collection, id, update)
+}
+
+func (c *Client) UpsertOneByIdCtx(ctx context.Context, collection string, id interface{}, update interface{}) (*mongo.UpdateResult, error) {
+	return c.UpsertOneCtx(ctx, collection, bson.M{"_id": id}, update)
 }
 
 func (c *Client) DeleteOne(collection string, filter interface{}) (*mongo.DeleteResult, error) {
-	ctx, cancel := createContext(c.timeout)
+	return c.DeleteOneCtx(context.Background(), collection, filter)
+}
+
+func (c *Client) DeleteOneCtx(ctx context.Context, collection string, filter interface{}) (*mongo.DeleteResult, error) {
+	if p := c.policy(collection); p.softDelete() {
+		return c.softDeleteCtx(ctx, p, collection, filter, false)
+	}
+	ctx, cancel := withTimeout(ctx, c.timeout)
 	defer cancel()
 	return c.db.Collection(collection).DeleteOne(ctx, filter)
 }
 
 func (c *Client) DeleteOneByField(collection string, field string, value interface{}) (*mongo.DeleteResult, error) {
-	return c.DeleteOne(collection, bson.M{field: value})
+	return c.DeleteOneByFieldCtx(context.Background(), collection, field, value)
+}
+
+func (c *Client) DeleteOneByFieldCtx(ctx context.Context, collection string, field string, value interface{}) (*mongo.DeleteResult, error) {
+	return c.DeleteOneCtx(ctx, collection, bson.M{field: value})
 }
 
 func (c *Client) DeleteOneById(collection string, id interface{}) (*mongo.DeleteResult, error) {
-	return c.DeleteOne(collection, bson.M{"_id": id})
+	return c.DeleteOneByIdCtx(context.Background(), collection, id)
+}
+
+func (c *Client) DeleteOneByIdCtx(ctx context.Context, collection string, id interface{}) (*mongo.DeleteResult, error) {
+	return c.DeleteOneCtx(ctx, collection, bson.M{"_id": id})
 }
 
 func (c *Client) DeleteMany(collection string, filter interface{}) (*mongo.DeleteResult, error) {
-	ctx, cancel := createContext(c.timeout)
+	return c.DeleteManyCtx(context.Background(), collection, filter)
+}
+
+func (c *Client) DeleteManyCtx(ctx context.Context, collection string, filter interface{}) (*mongo.DeleteResult, error) {
+	if p := c.policy(collection); p.softDelete() {
+		return c.softDeleteCtx(ctx, p, collection, filter, true)
+	}
+	ctx, cancel := withTimeout(ctx, c.timeout)
 	defer cancel()
 	return c.db.Collection(collection).DeleteMany(ctx, filter)
 }
 
 func createContext(timeout time.Duration) (context.Context, context.CancelFunc) {
-	return context.WithTimeout(context.Background(), timeout*time.Second)
+	return withTimeout(context.Background(), timeout)
 }
 
-func connect(uri string, timeout time.Duration) (*mongo.Client, error) {
-	ctx, _ := createContext(timeout)
-	client, err := mongo.Connect(ctx, options.Client().ApplyURI(uri))
-	return client, err
+// withTimeout applies the client timeout to ctx unless ctx already
+// carries an earlier deadline, which is then preserved as is.
+func withTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	deadline := time.Now().Add(timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		return context.WithCancel(ctx)
+	}
+	return context.WithDeadline(ctx, deadline)
 }
 
+// NewClient connects with the given timeout, e.g. 10*time.Second, and the
+// default options, see NewClientWithOptions for the others.
 func NewClient(uri string, database string, timeout time.Duration) (*Client, error) {
+	if timeout <= 0 {
+		return nil, fmt.Errorf("empty MongoDB timeout")
+	}
+	return NewClientWithOptions(uri, database, WithTimeout(timeout))
+}
+
+func NewClientWithOptions(uri string, database string, opts ...ClientOption) (*Client, error) {
 	if uri == "" {
 		return nil, fmt.Errorf("empty MongoDB URI")
 	}
 	if database == "" {
 		return nil, fmt.Errorf("empty MongoDB database")
 	}
-	if timeout == 0 {
+
+	cfg := &clientConfig{
+		timeout: DefaultTimeout,
+		options: options.Client().ApplyURI(uri),
+	}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	if cfg.timeout <= 0 {
 		return nil, fmt.Errorf("empty MongoDB timeout")
 	}
+	if err := cfg.options.Validate(); err != nil {
+		return nil, err
+	}
 
-	client, err := connect(uri, timeout)
+	ctx, cancel := createContext(cfg.timeout)
+	defer cancel()
+	client, err := mongo.Connect(ctx, cfg.options)
 	if err != nil {
 		return nil, err
 	}
 	return &Client{
 		client:  client,
 		db:      client.Database(database),
-		timeout: timeout,
+		timeout: cfg.timeout,
 	}, nil
 }
@@ -1,10 +1,13 @@
 package mongoutils
 
 import (
+	"context"
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/sandrolain/go-utilities/pkg/testmongoutils"
+	"github.com/stretchr/testify/assert"
 )
 
 func TestMain(m *testing.M) {
@@ -14,3 +17,48 @@ func TestMain(m *testing.M) {
 func TestURI(t *testing.T) {
 	fmt.Print(testmongoutils.GetMockServerURI())
 }
+
+func TestWithTimeout(t *testing.T) {
+	{
+		ctx, cancel := withTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		deadline, ok := ctx.Deadline()
+		assert.True(t, ok)
+		assert.WithinDuration(t, time.Now().Add(10*time.Second), deadline, time.Second)
+	}
+
+	{
+		parent, parentCancel := context.WithTimeout(context.Background(), time.Second)
+		defer parentCancel()
+		expected, _ := parent.Deadline()
+		ctx, cancel := withTimeout(parent, 10*time.Second)
+		defer cancel()
+		deadline, ok := ctx.Deadline()
+		assert.True(t, ok)
+		assert.Equal(t, expected, deadline)
+	}
+}
+
+func TestNewClientWithOptions(t *testing.T) {
+	_, err := NewClientWithOptions("", "test")
+	assert.Error(t, err)
+	_, err = NewClientWithOptions(testmongoutils.GetMockServerURI(), "test", WithTimeout(0))
+	assert.Error(t, err)
+
+	client, err := NewClientWithOptions(
+		testmongoutils.GetMockServerURI(),
+		"test",
+		WithTimeout(5*time.Second),
+		WithMaxPoolSize(10),
+		WithAppName("mongoutils-test"),
+		WithRetryableWrites(true),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	assert.Equal(t, 5*time.Second, client.Timeout())
+	if err := client.Ping(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,392 @@
+package mongoutils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sandrolain/go-utilities/pkg/logutils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"go.mongodb.org/mongo-driver/event"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const maxLoggedCommandLength = 1024
+
+// startedCommandTtl bounds how long a started command is tracked, so that
+// commands that never complete, e.g. on a dropped connection, are released.
+const startedCommandTtl = 10 * time.Minute
+
+var DefaultLatencyBuckets = []float64{0.001, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+type commandKey struct {
+	database string
+	command  string
+}
+
+type commandStats struct {
+	count   uint64
+	errors  uint64
+	sum     float64
+	buckets []uint64
+}
+
+type poolStats struct {
+	open            int64
+	inUse           int64
+	checkoutFailure uint64
+	cleared         uint64
+}
+
+type Metrics struct {
+	mu       sync.Mutex
+	buckets  []float64
+	commands map[commandKey]*commandStats
+	pools    map[string]*poolStats
+}
+
+func NewMetrics(buckets []float64) *Metrics {
+	if len(buckets) == 0 {
+		buckets = DefaultLatencyBuckets
+	}
+	sorted := make([]float64, len(buckets))
+	copy(sorted, buckets)
+	sort.Float64s(sorted)
+	return &Metrics{
+		buckets:  sorted,
+		commands: map[commandKey]*commandStats{},
+		pools:    map[string]*poolStats{},
+	}
+}
+
+func (m *Metrics) observeCommand(database string, command string, duration time.Duration, failed bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	key := commandKey{database, command}
+	stats, ok := m.commands[key]
+	if !ok {
+		stats = &commandStats{buckets: make([]uint64, len(m.buckets))}
+		m.commands[key] = stats
+	}
+	seconds := duration.Seconds()
+	stats.count++
+	stats.sum += seconds
+	if failed {
+		stats.errors++
+	}
+	for i, le := range m.buckets {
+		if seconds <= le {
+			stats.buckets[i]++
+		}
+	}
+}
+
+func (m *Metrics) observePool(evt *event.PoolEvent) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	stats, ok := m.pools[evt.Address]
+	if !ok {
+		stats = &poolStats{}
+		m.pools[evt.Address] = stats
+	}
+	switch evt.Type {
+	case event.ConnectionCreated:
+		stats.open++
+	case event.ConnectionClosed:
+		stats.open--
+	case event.GetSucceeded:
+		stats.inUse++
+	case event.ConnectionReturned:
+		stats.inUse--
+	case event.GetFailed:
+		stats.checkoutFailure++
+	case event.PoolCleared:
+		stats.cleared++
+	}
+}
+
+// WritePrometheus writes the collected metrics in the Prometheus text
+// exposition format.
+func (m *Metrics) WritePrometheus(w io.Writer) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var b strings.Builder
+
+	keys := make([]commandKey, 0, len(m.commands))
+	for k := range m.commands {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].database != keys[j].database {
+			return keys[i].database < keys[j].database
+		}
+		return keys[i].command < keys[j].command
+	})
+
+	b.WriteString("# HELP mongodb_command_duration_seconds MongoDB command latency.\n")
+	b.WriteString("# TYPE mongodb_command_duration_seconds histogram\n")
+	for _, k := range keys {
+		stats := m.commands[k]
+		labels := fmt.Sprintf("database=%q,command=%q", k.database, k.command)
+		for i, le := range m.buckets {
+			fmt.Fprintf(&b, "mongodb_command_duration_seconds_bucket{%s,le=\"%g\"} %d\n", labels, le, stats.buckets[i])
+		}
+		fmt.Fprintf(&b, "mongodb_command_duration_seconds_bucket{%s,le=\"+Inf\"} %d\n", labels, stats.count)
+		fmt.Fprintf(&b, "mongodb_command_duration_seconds_sum{%s} %g\n", labels, stats.sum)
+		fmt.Fprintf(&b, "mongodb_command_duration_seconds_count{%s} %d\n", labels, stats.count)
+	}
+	b.WriteString("# HELP mongodb_command_errors_total MongoDB failed commands.\n")
+	b.WriteString("# TYPE mongodb_command_errors_total counter\n")
+	for _, k := range keys {
+		fmt.Fprintf(&b, "mongodb_command_errors_total{database=%q,command=%q} %d\n", k.database, k.command, m.commands[k].errors)
+	}
+
+	addresses := make([]string, 0, len(m.pools))
+	for a := range m.pools {
+		addresses = append(addresses, a)
+	}
+	sort.Strings(addresses)
+	poolMetrics := []struct {
+		name  string
+		help  string
+		kind  string
+		value func(*poolStats) interface{}
+	}{
+		{"mongodb_pool_connections", "Open pool connections.", "gauge", func(s *poolStats) interface{} { return s.open }},
+		{"mongodb_pool_connections_in_use", "Checked out pool connections.", "gauge", func(s *poolStats) interface{} { return s.inUse }},
+		{"mongodb_pool_checkout_failures_total", "Failed connection checkouts.", "counter", func(s *poolStats) interface{} { return s.checkoutFailure }},
+		{"mongodb_pool_cleared_total", "Pool clear events.", "counter", func(s *poolStats) interface{} { return s.cleared }},
+	}
+	for _, pm := range poolMetrics {
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s %s\n", pm.name, pm.help, pm.name, pm.kind)
+		for _, a := range addresses {
+			fmt.Fprintf(&b, "%s{address=%q} %v\n", pm.name, a, pm.value(m.pools[a]))
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func (m *Metrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	if err := m.WritePrometheus(w); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+type Span interface {
+	SetError(err error)
+	End()
+}
+
+type Tracer interface {
+	StartSpan(ctx context.Context, name string, attributes map[string]string) Span
+}
+
+type MonitorOptions struct {
+	Metrics       *Metrics
+	SlowThreshold time.Duration
+	SlowLog       func(msg string, args ...interface{})
+	Tracer        Tracer
+}
+
+type startedCommand struct {
+	start    time.Time
+	database string
+	command  string
+	redacted string
+	span     Span
+}
+
+type monitor struct {
+	opts      MonitorOptions
+	mu        sync.Mutex
+	started   map[int64]*startedCommand
+	lastSweep time.Time
+}
+
+// NewMonitors builds the driver command and pool monitors. Slow commands are
+// logged with every filter value redacted, as warnings of logutils unless a
+// SlowLog function is supplied.
+func NewMonitors(opts MonitorOptions) (*event.CommandMonitor, *event.PoolMonitor) {
+	if opts.SlowThreshold > 0 && opts.SlowLog == nil {
+		opts.SlowLog = logutils.Warnf
+	}
+	m := &monitor{
+		opts:      opts,
+		started:   map[int64]*startedCommand{},
+		lastSweep: time.Now(),
+	}
+	commands := &event.CommandMonitor{
+		Started:   m.commandStarted,
+		Succeeded: m.commandSucceeded,
+		Failed:    m.commandFailed,
+	}
+	pool := &event.PoolMonitor{
+		Event: m.poolEvent,
+	}
+	return commands, pool
+}
+
+func WithMonitoring(opts MonitorOptions) ClientOption {
+	commands, pool := NewMonitors(opts)
+	return WithClientOptions(func(o *options.ClientOptions) {
+		o.SetMonitor(commands)
+		o.SetPoolMonitor(pool)
+	})
+}
+
+func (m *monitor) commandStarted(ctx context.Context, evt *event.CommandStartedEvent) {
+	sc := &startedCommand{
+		start:    time.Now(),
+		database: evt.DatabaseName,
+		command:  evt.CommandName,
+	}
+	if m.opts.SlowThreshold > 0 {
+		sc.redacted = RedactCommand(evt.Command)
+	}
+	if m.opts.Tracer != nil {
+		attrs := map[string]string{
+			"db.system":    "mongodb",
+			"db.name":      evt.DatabaseName,
+			"db.operation": evt.CommandName,
+		}
+		if coll, ok := evt.Command.Lookup(evt.CommandName).StringValueOK(); ok {
+			attrs["db.mongodb.collection"] = coll
+		}
+		sc.span = m.opts.Tracer.StartSpan(ctx, "mongodb."+evt.CommandName, attrs)
+	}
+	m.mu.Lock()
+	m.started[evt.RequestID] = sc
+	expired := m.sweep(sc.start)
+	m.mu.Unlock()
+	for _, e := range expired {
+		if e.span != nil {
+			e.span.SetError(fmt.Errorf("%s command did not complete", e.command))
+			e.span.End()
+		}
+	}
+}
+
+// sweep removes the commands started before the TTL, at most once every
+// tenth of it.
+func (m *monitor) sweep(now time.Time) []*startedCommand {
+	if now.Sub(m.lastSweep) < startedCommandTtl/10 {
+		return nil
+	}
+	m.lastSweep = now
+	var expired []*startedCommand
+	for id, sc := range m.started {
+		if now.Sub(sc.start) >= startedCommandTtl {
+			delete(m.started, id)
+			expired = append(expired, sc)
+		}
+	}
+	return expired
+}
+
+func (m *monitor) commandSucceeded(ctx context.Context, evt *event.CommandSucceededEvent) {
+	m.commandFinished(&evt.CommandFinishedEvent, nil)
+}
+
+func (m *monitor) commandFailed(ctx context.Context, evt *event.CommandFailedEvent) {
+	m.commandFinished(&evt.CommandFinishedEvent, errors.New(evt.Failure))
+}
+
+func (m *monitor) commandFinished(evt *event.CommandFinishedEvent, err error) {
+	m.mu.Lock()
+	sc, ok := m.started[evt.RequestID]
+	delete(m.started, evt.RequestID)
+	m.mu.Unlock()
+	if !ok {
+		return
+	}
+	if m.opts.Metrics != nil {
+		m.opts.Metrics.observeCommand(sc.database, sc.command, evt.Duration, err != nil)
+	}
+	if m.opts.SlowThreshold > 0 && evt.Duration >= m.opts.SlowThreshold {
+		m.opts.SlowLog("slow MongoDB command %s on %s took %v: %s", sc.command, sc.database, evt.Duration, sc.redacted)
+	}
+	if sc.span != nil {
+		if err != nil {
+			sc.span.SetError(err)
+		}
+		sc.span.End()
+	}
+}
+
+func (m *monitor) poolEvent(evt *event.PoolEvent) {
+	if m.opts.Metrics != nil {
+		m.opts.Metrics.observePool(evt)
+	}
+}
+
+var ignoredCommandFields = map[string]bool{
+	"lsid":         true,
+	"$db":          true,
+	"$clusterTime": true,
+	"txnNumber":    true,
+}
+
+// RedactCommand renders a command as extended JSON keeping its structure and
+// operators while replacing every value with "?".
+func RedactCommand(cmd bson.Raw) string {
+	elems, err := cmd.Elements()
+	if err != nil {
+		return ""
+	}
+	doc := bson.D{}
+	for i, e := range elems {
+		key := e.Key()
+		if ignoredCommandFields[key] {
+			continue
+		}
+		if i == 0 {
+			doc = append(doc, bson.E{Key: key, Value: e.Value()})
+			continue
+		}
+		doc = append(doc, bson.E{Key: key, Value: redactValue(e.Value())})
+	}
+	b, err := bson.MarshalExtJSON(doc, false, false)
+	if err != nil {
+		return ""
+	}
+	if len(b) > maxLoggedCommandLength {
+		return string(b[:maxLoggedCommandLength]) + "..."
+	}
+	return string(b)
+}
+
+func redactValue(v bson.RawValue) interface{} {
+	switch v.Type {
+	case bsontype.EmbeddedDocument:
+		elems, err := v.Document().Elements()
+		if err != nil {
+			return "?"
+		}
+		doc := make(bson.D, len(elems))
+		for i, e := range elems {
+			doc[i] = bson.E{Key: e.Key(), Value: redactValue(e.Value())}
+		}
+		return doc
+	case bsontype.Array:
+		values, err := v.Array().Values()
+		if err != nil {
+			return "?"
+		}
+		arr := make(bson.A, len(values))
+		for i, e := range values {
+			arr[i] = redactValue(e)
+		}
+		return arr
+	}
+	return "?"
+}
@@ -0,0 +1,119 @@
+package mongoutils
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/event"
+)
+
+type testSpan struct {
+	name  string
+	err   error
+	ended bool
+}
+
+func (s *testSpan) SetError(err error) {
+	s.err = err
+}
+
+func (s *testSpan) End() {
+	s.ended = true
+}
+
+type testTracer struct {
+	spans []*testSpan
+}
+
+func (t *testTracer) StartSpan(ctx context.Context, name string, attributes map[string]string) Span {
+	span := &testSpan{name: name}
+	t.spans = append(t.spans, span)
+	return span
+}
+
+func TestRedactCommand(t *testing.T) {
+	cmd, _ := bson.Marshal(bson.D{
+		{Key: "find", Value: "users"},
+		{Key: "filter", Value: bson.D{{Key: "email", Value: "secret@example.com"}, {Key: "age", Value: bson.M{"$gt": 18}}}},
+		{Key: "$db", Value: "test"},
+	})
+	assert.Equal(t, `{"find":"users","filter":{"email":"?","age":{"$gt":"?"}}}`, RedactCommand(cmd))
+}
+
+func TestMonitors(t *testing.T) {
+	metrics := NewMetrics([]float64{0.1, 1})
+	tracer := &testTracer{}
+	logged := []string{}
+	commands, pool := NewMonitors(MonitorOptions{
+		Metrics:       metrics,
+		SlowThreshold: 500 * time.Millisecond,
+		SlowLog: func(msg string, args ...interface{}) {
+			logged = append(logged, fmt.Sprintf(msg, args...))
+		},
+		Tracer: tracer,
+	})
+
+	cmd, _ := bson.Marshal(bson.D{{Key: "find", Value: "users"}, {Key: "filter", Value: bson.M{"name": "x"}}})
+	ctx := context.Background()
+	commands.Started(ctx, &event.CommandStartedEvent{Command: cmd, DatabaseName: "test", CommandName: "find", RequestID: 1})
+	commands.Succeeded(ctx, &event.CommandSucceededEvent{CommandFinishedEvent: event.CommandFinishedEvent{CommandName: "find", RequestID: 1, Duration: 50 * time.Millisecond}})
+	commands.Started(ctx, &event.CommandStartedEvent{Command: cmd, DatabaseName: "test", CommandName: "find", RequestID: 2})
+	commands.Failed(ctx, &event.CommandFailedEvent{CommandFinishedEvent: event.CommandFinishedEvent{CommandName: "find", RequestID: 2, Duration: 2 * time.Second}, Failure: "boom"})
+
+	pool.Event(&event.PoolEvent{Type: event.ConnectionCreated, Address: "localhost:27017"})
+	pool.Event(&event.PoolEvent{Type: event.GetSucceeded, Address: "localhost:27017"})
+
+	assert.Len(t, logged, 1)
+	assert.Contains(t, logged[0], `{"find":"users","filter":{"name":"?"}}`)
+	assert.Len(t, tracer.spans, 2)
+	assert.True(t, tracer.spans[0].ended)
+	assert.Nil(t, tracer.spans[0].err)
+	assert.EqualError(t, tracer.spans[1].err, "boom")
+
+	var out strings.Builder
+	if err := metrics.WritePrometheus(&out); err != nil {
+		t.Fatal(err)
+	}
+	text := out.String()
+	assert.Contains(t, text, `mongodb_command_duration_seconds_bucket{database="test",command="find",le="0.1"} 1`)
+	assert.Contains(t, text, `mongodb_command_duration_seconds_count{database="test",command="find"} 2`)
+	assert.Contains(t, text, `mongodb_command_errors_total{database="test",command="find"} 1`)
+	assert.Contains(t, text, `mongodb_pool_connections_in_use{address="localhost:27017"} 1`)
+}
+
+func TestMonitorExpiresStartedCommands(t *testing.T) {
+	tracer := &testTracer{}
+	m := &monitor{
+		opts:    MonitorOptions{SlowThreshold: time.Second, Tracer: tracer},
+		started: map[int64]*startedCommand{},
+	}
+
+	cmd, _ := bson.Marshal(bson.D{{Key: "find", Value: "users"}})
+	ctx := context.Background()
+	m.commandStarted(ctx, &event.CommandStartedEvent{Command: cmd, DatabaseName: "test", CommandName: "find", RequestID: 1})
+	m.started[1].start = time.Now().Add(-startedCommandTtl)
+	m.lastSweep = time.Now().Add(-startedCommandTtl)
+	m.commandStarted(ctx, &event.CommandStartedEvent{Command: cmd, DatabaseName: "test", CommandName: "find", RequestID: 2})
+
+	assert.Len(t, m.started, 1)
+	assert.Contains(t, m.started, int64(2))
+	assert.True(t, tracer.spans[0].ended)
+	assert.NotNil(t, tracer.spans[0].err)
+	assert.False(t, tracer.spans[1].ended)
+
+	// a late completion of an expired command is ignored
+	m.commandSucceeded(ctx, &event.CommandSucceededEvent{CommandFinishedEvent: event.CommandFinishedEvent{CommandName: "find", RequestID: 1, Duration: time.Hour}})
+}
+
+func TestMonitorDefaultSlowLog(t *testing.T) {
+	commands, _ := NewMonitors(MonitorOptions{SlowThreshold: time.Millisecond})
+	cmd, _ := bson.Marshal(bson.D{{Key: "find", Value: "users"}})
+	ctx := context.Background()
+	commands.Started(ctx, &event.CommandStartedEvent{Command: cmd, DatabaseName: "test", CommandName: "find", RequestID: 1})
+	commands.Succeeded(ctx, &event.CommandSucceededEvent{CommandFinishedEvent: event.CommandFinishedEvent{CommandName: "find", RequestID: 1, Duration: time.Second}})
+}
@@ -0,0 +1,104 @@
+package mongoutils
+
+import (
+	"crypto/tls"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readconcern"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+	"go.mongodb.org/mongo-driver/mongo/writeconcern"
+)
+
+const DefaultTimeout = 10 * time.Second
+
+type clientConfig struct {
+	timeout time.Duration
+	options *options.ClientOptions
+}
+
+type ClientOption func(cfg *clientConfig)
+
+func WithTimeout(timeout time.Duration) ClientOption {
+	return func(cfg *clientConfig) {
+		cfg.timeout = timeout
+	}
+}
+
+func WithMaxPoolSize(size uint64) ClientOption {
+	return func(cfg *clientConfig) {
+		cfg.options.SetMaxPoolSize(size)
+	}
+}
+
+func WithMinPoolSize(size uint64) ClientOption {
+	return func(cfg *clientConfig) {
+		cfg.options.SetMinPoolSize(size)
+	}
+}
+
+func WithMaxConnIdleTime(d time.Duration) ClientOption {
+	return func(cfg *clientConfig) {
+		cfg.options.SetMaxConnIdleTime(d)
+	}
+}
+
+func WithReadPreference(rp *readpref.ReadPref) ClientOption {
+	return func(cfg *clientConfig) {
+		cfg.options.SetReadPreference(rp)
+	}
+}
+
+func WithReadConcern(rc *readconcern.ReadConcern) ClientOption {
+	return func(cfg *clientConfig) {
+		cfg.options.SetReadConcern(rc)
+	}
+}
+
+func WithWriteConcern(wc *writeconcern.WriteConcern) ClientOption {
+	return func(cfg *clientConfig) {
+		cfg.options.SetWriteConcern(wc)
+	}
+}
+
+func WithRetryableWrites(retry bool) ClientOption {
+	return func(cfg *clientConfig) {
+		cfg.options.SetRetryWrites(retry)
+	}
+}
+
+func WithAppName(name string) ClientOption {
+	return func(cfg *clientConfig) {
+		cfg.options.SetAppName(name)
+	}
+}
+
+func WithCompressors(compressors ...string) ClientOption {
+	return func(cfg *clientConfig) {
+		cfg.options.SetCompressors(compressors)
+	}
+}
+
+func WithTLSConfig(tlsConfig *tls.Config) ClientOption {
+	return func(cfg *clientConfig) {
+		cfg.options.SetTLSConfig(tlsConfig)
+	}
+}
+
+func WithCredentials(username string, password string, authSource string) ClientOption {
+	return func(cfg *clientConfig) {
+		cfg.options.SetAuth(options.Credential{
+			Username:   username,
+			Password:   password,
+			AuthSource: authSource,
+		})
+	}
+}
+
+// WithClientOptions gives access to the driver options for settings not
+// covered by the other ClientOption functions.
+func WithClientOptions(fn func(opts *options.ClientOptions)) ClientOption {
+	return func(cfg *clientConfig) {
+		fn(cfg.options)
+	}
+}
@@ -0,0 +1,236 @@
+package mongoutils
+
+import (
+	"context"
+	"encoding/base64"
+	"strings"
+
+	"github.com/sandrolain/go-utilities/pkg/crudutils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type PageRequest struct {
+	Filter interface{}
+	Sort   bson.D
+	Size   int64
+	Token  string
+	Count  bool
+}
+
+type Page[T interface{}] struct {
+	Items     []T
+	NextToken string
+	Total     int64
+}
+
+func FindPage[T interface{}](c *Client, collection string, req PageRequest) (*Page[T], error) {
+	return FindPageCtx[T](context.Background(), c, collection, req)
+}
+
+func FindPageCtx[T interface{}](ctx context.Context, c *Client, collection string, req PageRequest) (*Page[T], error) {
+	if req.Size <= 0 {
+		return nil, crudutils.InvalidValue("page size")
+	}
+	ctx, cancel := withTimeout(ctx, c.timeout)
+	defer cancel()
+
+	filter := c.policy(collection).activeFilter(req.Filter)
+	if filter == nil {
+		filter = bson.M{}
+	}
+	sort := keysetSort(req.Sort)
+
+	page := &Page[T]{
+		Items: make([]T, 0, req.Size),
+	}
+	if req.Count {
+		total, err := c.Coll(collection).CountDocuments(ctx, filter)
+		if err != nil {
+			return nil, err
+		}
+		page.Total = total
+	}
+
+	find := filter
+	if req.Token != "" {
+		values, err := decodePageToken(req.Token, len(sort))
+		if err != nil {
+			return nil, err
+		}
+		find = bson.D{{Key: "$and", Value: bson.A{filter, keysetFilter(sort, values)}}}
+	}
+
+	opts := options.Find().SetSort(sort).SetLimit(req.Size + 1)
+	cursor, err := c.Coll(collection).Find(ctx, find, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var last bson.Raw
+	for cursor.Next(ctx) {
+		if int64(len(page.Items)) == req.Size {
+			token, err := encodePageToken(sort, last)
+			if err != nil {
+				return nil, err
+			}
+			page.NextToken = token
+			break
+		}
+		var item T
+		if err := cursor.Decode(&item); err != nil {
+			return nil, err
+		}
+		page.Items = append(page.Items, item)
+		last = append(bson.Raw{}, cursor.Current...)
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, err
+	}
+	return page, nil
+}
+
+func keysetSort(sort bson.D) bson.D {
+	res := make(bson.D, 0, len(sort)+1)
+	for _, e := range sort {
+		res = append(res, e)
+		if e.Key == "_id" {
+			return res
+		}
+	}
+	return append(res, bson.E{Key: "_id", Value: 1})
+}
+
+// keysetFilter matches the documents following values in the sort order.
+// MongoDB sorts null and missing fields before any other value, so nothing
+// follows a null in descending order while nulls follow every other value.
+func keysetFilter(sort bson.D, values bson.A) bson.D {
+	or := make(bson.A, 0, len(sort))
+	for i, e := range sort {
+		cond := make(bson.D, 0, i+1)
+		for j := 0; j < i; j++ {
+			cond = append(cond, bson.E{Key: sort[j].Key, Value: values[j]})
+		}
+		desc := isDescending(e.Value)
+		switch {
+		case values[i] == nil && desc:
+			continue
+		case values[i] == nil:
+			cond = append(cond, bson.E{Key: e.Key, Value: bson.M{"$ne": nil}})
+		case desc:
+			cond = append(cond, bson.E{Key: "$or", Value: bson.A{
+				bson.D{{Key: e.Key, Value: bson.M{"$lt": values[i]}}},
+				bson.D{{Key: e.Key, Value: nil}},
+			}})
+		default:
+			cond = append(cond, bson.E{Key: e.Key, Value: bson.M{"$gt": values[i]}})
+		}
+		or = append(or, cond)
+	}
+	return bson.D{{Key: "$or", Value: or}}
+}
+
+func isDescending(direction interface{}) bool {
+	switch v := direction.(type) {
+	case int:
+		return v < 0
+	case int32:
+		return v < 0
+	case int64:
+		return v < 0
+	case float64:
+		return v < 0
+	}
+	return false
+}
+
+func encodePageToken(sort bson.D, doc bson.Raw) (string, error) {
+	values := make(bson.A, len(sort))
+	for i, e := range sort {
+		// missing fields sort as null
+		val, err := doc.LookupErr(strings.Split(e.Key, ".")...)
+		if err == nil && val.Type != bsontype.Null {
+			values[i] = val
+		}
+	}
+	b, err := bson.Marshal(bson.M{"v": values})
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func decodePageToken(token string, size int) (bson.A, error) {
+	b, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, crudutils.InvalidValue("page token")
+	}
+	var data struct {
+		V bson.A `bson:"v"`
+	}
+	if err := bson.Unmarshal(b, &data); err != nil || len(data.V) != size {
+		return nil, crudutils.InvalidValue("page token")
+	}
+	return data.V, nil
+}
+
+type Iterator[T interface{}] struct {
+	ctx    context.Context
+	cursor *mongo.Cursor
+	value  T
+	err    error
+}
+
+func Iterate[T interface{}](ctx context.Context, c *Client, collection string, filter interface{}, sort interface{}, batchSize int32) (*Iterator[T], error) {
+	findCtx, cancel := withTimeout(ctx, c.timeout)
+	defer cancel()
+	opts := options.Find()
+	if sort != nil {
+		opts.SetSort(sort)
+	}
+	if batchSize > 0 {
+		opts.SetBatchSize(batchSize)
+	}
+	filter = c.policy(collection).activeFilter(filter)
+	if filter == nil {
+		filter = bson.M{}
+	}
+	cursor, err := c.Coll(collection).Find(findCtx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &Iterator[T]{
+		ctx:    ctx,
+		cursor: cursor,
+	}, nil
+}
+
+func (it *Iterator[T]) Next() bool {
+	if it.err != nil || !it.cursor.Next(it.ctx) {
+		return false
+	}
+	var value T
+	if it.err = it.cursor.Decode(&value); it.err != nil {
+		return false
+	}
+	it.value = value
+	return true
+}
+
+func (it *Iterator[T]) Value() T {
+	return it.value
+}
+
+func (it *Iterator[T]) Err() error {
+	if it.err != nil {
+		return it.err
+	}
+	return it.cursor.Err()
+}
+
+func (it *Iterator[T]) Close() error {
+	return it.cursor.Close(context.Background())
+}
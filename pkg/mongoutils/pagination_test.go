@@ -0,0 +1,161 @@
+package mongoutils
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestKeysetSort(t *testing.T) {
+	assert.Equal(t, bson.D{{Key: "age", Value: -1}, {Key: "_id", Value: 1}}, keysetSort(bson.D{{Key: "age", Value: -1}}))
+	assert.Equal(t, bson.D{{Key: "_id", Value: -1}}, keysetSort(bson.D{{Key: "_id", Value: -1}, {Key: "age", Value: 1}}))
+}
+
+func TestPageToken(t *testing.T) {
+	sort := keysetSort(bson.D{{Key: "age", Value: 1}})
+	doc, _ := bson.Marshal(bson.M{"_id": "x", "age": int32(42)})
+
+	token, err := encodePageToken(sort, doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	values, err := decodePageToken(token, len(sort))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, bson.A{int32(42), "x"}, values)
+
+	_, err = decodePageToken(token, 1)
+	assert.Error(t, err)
+	_, err = decodePageToken("not a token", len(sort))
+	assert.Error(t, err)
+}
+
+func TestPageTokenNull(t *testing.T) {
+	sort := keysetSort(bson.D{{Key: "profile.age", Value: 1}})
+	for _, v := range []bson.M{{"_id": "x"}, {"_id": "x", "profile": nil}, {"_id": "x", "profile": bson.M{"age": nil}}} {
+		doc, _ := bson.Marshal(v)
+		token, err := encodePageToken(sort, doc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		values, err := decodePageToken(token, len(sort))
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, bson.A{nil, "x"}, values)
+	}
+}
+
+func TestKeysetFilterNull(t *testing.T) {
+	asc := bson.D{{Key: "age", Value: 1}, {Key: "_id", Value: 1}}
+	assert.Equal(t, bson.D{{Key: "$or", Value: bson.A{
+		bson.D{{Key: "age", Value: bson.M{"$ne": nil}}},
+		bson.D{{Key: "age", Value: nil}, {Key: "_id", Value: bson.M{"$gt": "x"}}},
+	}}}, keysetFilter(asc, bson.A{nil, "x"}))
+
+	desc := bson.D{{Key: "age", Value: -1}, {Key: "_id", Value: 1}}
+	assert.Equal(t, bson.D{{Key: "$or", Value: bson.A{
+		bson.D{{Key: "age", Value: nil}, {Key: "_id", Value: bson.M{"$gt": "x"}}},
+	}}}, keysetFilter(desc, bson.A{nil, "x"}))
+	assert.Equal(t, bson.D{{Key: "$or", Value: bson.A{
+		bson.D{{Key: "$or", Value: bson.A{
+			bson.D{{Key: "age", Value: bson.M{"$lt": 3}}},
+			bson.D{{Key: "age", Value: nil}},
+		}}},
+		bson.D{{Key: "age", Value: 3}, {Key: "_id", Value: bson.M{"$gt": "x"}}},
+	}}}, keysetFilter(desc, bson.A{3, "x"}))
+}
+
+func TestFindPageNullSortField(t *testing.T) {
+	client := newTestClient(t)
+	docs := make([]interface{}, 12)
+	for i := range docs {
+		doc := bson.M{"_id": fmt.Sprintf("n%02d", i), "name": "nulls"}
+		switch i % 3 {
+		case 0:
+			doc["age"] = i
+		case 1:
+			doc["age"] = nil
+		}
+		docs[i] = doc
+	}
+	if _, err := client.InsertMany("pagination_nulls", docs); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, direction := range []int{1, -1} {
+		seen := map[string]bool{}
+		req := PageRequest{
+			Filter: bson.M{"name": "nulls"},
+			Sort:   bson.D{{Key: "age", Value: direction}},
+			Size:   5,
+		}
+		for {
+			page, err := FindPage[bson.M](client, "pagination_nulls", req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, doc := range page.Items {
+				assert.False(t, seen[doc["_id"].(string)])
+				seen[doc["_id"].(string)] = true
+			}
+			if page.NextToken == "" {
+				break
+			}
+			req.Token = page.NextToken
+		}
+		assert.Len(t, seen, 12)
+	}
+}
+
+func TestFindPageAndIterate(t *testing.T) {
+	client := newTestClient(t)
+	docs := make([]interface{}, 25)
+	for i := range docs {
+		docs[i] = TestDoc{ID: fmt.Sprintf("p%02d", i), Name: "page", Age: i % 5}
+	}
+	if _, err := client.InsertMany("pagination", docs); err != nil {
+		t.Fatal(err)
+	}
+
+	seen := map[string]bool{}
+	req := PageRequest{
+		Filter: bson.M{"name": "page"},
+		Sort:   bson.D{{Key: "age", Value: -1}},
+		Size:   10,
+		Count:  true,
+	}
+	for {
+		page, err := FindPage[TestDoc](client, "pagination", req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, int64(25), page.Total)
+		for _, doc := range page.Items {
+			seen[doc.ID] = true
+		}
+		if page.NextToken == "" {
+			break
+		}
+		req.Token = page.NextToken
+	}
+	assert.Len(t, seen, 25)
+
+	it, err := Iterate[TestDoc](context.Background(), client, "pagination", bson.M{"name": "page"}, nil, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer it.Close()
+	count := 0
+	for it.Next() {
+		count++
+	}
+	if err := it.Err(); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 25, count)
+}
@@ -0,0 +1,296 @@
+package mongoutils
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type CollectionPolicy struct {
+	SoftDelete     bool
+	Audit          bool
+	CreatedAtField string
+	UpdatedAtField string
+	DeletedAtField string
+	CreatedByField string
+	UpdatedByField string
+}
+
+func DefaultCollectionPolicy() CollectionPolicy {
+	return CollectionPolicy{
+		SoftDelete:     true,
+		Audit:          true,
+		CreatedAtField: "createdAt",
+		UpdatedAtField: "updatedAt",
+		DeletedAtField: "deletedAt",
+		CreatedByField: "createdBy",
+		UpdatedByField: "updatedBy",
+	}
+}
+
+type actorKey struct{}
+
+// WithActor stores the identity stamped in the createdBy and updatedBy
+// audit fields by the Ctx methods of collections with an audit policy.
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+func ActorFromContext(ctx context.Context) string {
+	actor, _ := ctx.Value(actorKey{}).(string)
+	return actor
+}
+
+func (c *Client) SetCollectionPolicy(collection string, policy CollectionPolicy) {
+	c.policiesMu.Lock()
+	defer c.policiesMu.Unlock()
+	if c.policies == nil {
+		c.policies = map[string]*CollectionPolicy{}
+	}
+	c.policies[collection] = &policy
+}
+
+func (c *Client) RemoveCollectionPolicy(collection string) {
+	c.policiesMu.Lock()
+	defer c.policiesMu.Unlock()
+	delete(c.policies, collection)
+}
+
+func (c *Client) policy(collection string) *CollectionPolicy {
+	c.policiesMu.RLock()
+	defer c.policiesMu.RUnlock()
+	return c.policies[collection]
+}
+
+func (p *CollectionPolicy) softDelete() bool {
+	return p != nil && p.SoftDelete && p.DeletedAtField != ""
+}
+
+func (p *CollectionPolicy) audit() bool {
+	return p != nil && p.Audit
+}
+
+func (p *CollectionPolicy) activeFilter(filter interface{}) interface{} {
+	if !p.softDelete() {
+		return filter
+	}
+	return andFilter(filter, bson.D{{Key: p.DeletedAtField, Value: nil}})
+}
+
+// activePipeline prepends a $match stage excluding soft deleted documents.
+func (p *CollectionPolicy) activePipeline(stages mongo.Pipeline) mongo.Pipeline {
+	if !p.softDelete() {
+		return stages
+	}
+	res := make(mongo.Pipeline, 0, len(stages)+1)
+	res = append(res, bson.D{{Key: "$match", Value: p.activeFilter(nil)}})
+	return append(res, stages...)
+}
+
+func (p *CollectionPolicy) deletedFilter(filter interface{}) interface{} {
+	return andFilter(filter, bson.D{{Key: p.DeletedAtField, Value: bson.M{"$ne": nil}}})
+}
+
+func andFilter(filter interface{}, cond bson.D) interface{} {
+	if filter == nil {
+		return cond
+	}
+	return bson.D{{Key: "$and", Value: bson.A{filter, cond}}}
+}
+
+func (p *CollectionPolicy) stampInsert(ctx context.Context, v interface{}) (interface{}, error) {
+	if !p.audit() {
+		return v, nil
+	}
+	doc, err := toDocument(v)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+	actor := ActorFromContext(ctx)
+	doc = setField(doc, p.CreatedAtField, now)
+	doc = setField(doc, p.UpdatedAtField, now)
+	if actor != "" {
+		doc = setField(doc, p.CreatedByField, actor)
+		doc = setField(doc, p.UpdatedByField, actor)
+	}
+	return doc, nil
+}
+
+func (p *CollectionPolicy) stampUpdate(ctx context.Context, update interface{}, upsert bool) (interface{}, error) {
+	if !p.audit() && !p.softDelete() {
+		return update, nil
+	}
+	res, err := toUpdate(update)
+	if err != nil {
+		return nil, err
+	}
+	// the policy fields of a whole document passed as update are owned by
+	// the policy, and $set would conflict with the stamped operators
+	res.remove("$set", p.ownFields()...)
+	if !p.audit() {
+		return res, nil
+	}
+	now := time.Now().UTC()
+	p.stampModified(ctx, res, now)
+	if upsert {
+		stampField(res, "$setOnInsert", p.CreatedAtField, now)
+		if actor := ActorFromContext(ctx); actor != "" {
+			stampField(res, "$setOnInsert", p.CreatedByField, actor)
+		}
+	}
+	return res, nil
+}
+
+// stampReplace returns an update pipeline replacing the matched document
+// with replacement, stamped like an update but keeping the stored creation
+// fields.
+func (p *CollectionPolicy) stampReplace(ctx context.Context, replacement interface{}) (mongo.Pipeline, error) {
+	doc, err := toDocument(replacement)
+	if err != nil {
+		return nil, err
+	}
+	doc = removeFields(doc, p.ownFields()...)
+	now := time.Now().UTC()
+	doc = setField(doc, p.UpdatedAtField, now)
+	if actor := ActorFromContext(ctx); actor != "" {
+		doc = setField(doc, p.UpdatedByField, actor)
+	}
+	stored := bson.D{{Key: "_id", Value: "$_id"}}
+	for _, field := range []string{p.CreatedAtField, p.CreatedByField} {
+		if field != "" {
+			stored = append(stored, bson.E{Key: field, Value: "$" + field})
+		}
+	}
+	merged := bson.A{bson.D{{Key: "$literal", Value: doc}}, stored}
+	return mongo.Pipeline{{{Key: "$replaceWith", Value: bson.D{{Key: "$mergeObjects", Value: merged}}}}}, nil
+}
+
+func (p *CollectionPolicy) deleteUpdate(ctx context.Context) *Update {
+	now := time.Now().UTC()
+	update := NewUpdate().Set(p.DeletedAtField, now)
+	if p.audit() {
+		p.stampModified(ctx, update, now)
+	}
+	return update
+}
+
+func (p *CollectionPolicy) stampModified(ctx context.Context, u *Update, now time.Time) {
+	stampField(u, "$set", p.UpdatedAtField, now)
+	if actor := ActorFromContext(ctx); actor != "" {
+		stampField(u, "$set", p.UpdatedByField, actor)
+	}
+}
+
+// ownFields returns the fields written only by the policy.
+func (p *CollectionPolicy) ownFields() []string {
+	fields := []string{}
+	if p.audit() {
+		fields = append(fields, p.CreatedAtField, p.CreatedByField, p.UpdatedAtField, p.UpdatedByField)
+	}
+	if p.softDelete() {
+		fields = append(fields, p.DeletedAtField)
+	}
+	return fields
+}
+
+func stampField(u *Update, op string, field string, value interface{}) {
+	if field != "" {
+		u.add(op, field, value)
+	}
+}
+
+func toDocument(v interface{}) (bson.D, error) {
+	b, err := bson.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	doc := bson.D{}
+	err = bson.Unmarshal(b, &doc)
+	return doc, err
+}
+
+func setField(doc bson.D, field string, value interface{}) bson.D {
+	if field == "" {
+		return doc
+	}
+	for i, e := range doc {
+		if e.Key == field {
+			doc[i].Value = value
+			return doc
+		}
+	}
+	return append(doc, bson.E{Key: field, Value: value})
+}
+
+// removeFields returns a copy of doc without fields.
+func removeFields(doc bson.D, fields ...string) bson.D {
+	res := make(bson.D, 0, len(doc))
+next:
+	for _, e := range doc {
+		for _, field := range fields {
+			if e.Key == field {
+				continue next
+			}
+		}
+		res = append(res, e)
+	}
+	return res
+}
+
+func (c *Client) Restore(collection string, filter interface{}) (*mongo.UpdateResult, error) {
+	return c.RestoreCtx(context.Background(), collection, filter)
+}
+
+func (c *Client) RestoreCtx(ctx context.Context, collection string, filter interface{}) (*mongo.UpdateResult, error) {
+	p := c.policy(collection)
+	if !p.softDelete() {
+		return &mongo.UpdateResult{}, nil
+	}
+	update := NewUpdate().Unset(p.DeletedAtField)
+	if p.audit() {
+		p.stampModified(ctx, update, time.Now().UTC())
+	}
+	ctx, cancel := withTimeout(ctx, c.timeout)
+	defer cancel()
+	return c.db.Collection(collection).UpdateMany(ctx, p.deletedFilter(filter), update.Document())
+}
+
+func (c *Client) RestoreById(collection string, id interface{}) (*mongo.UpdateResult, error) {
+	return c.RestoreCtx(context.Background(), collection, bson.M{"_id": id})
+}
+
+// Purge permanently removes the soft deleted documents matching filter.
+func (c *Client) Purge(collection string, filter interface{}) (*mongo.DeleteResult, error) {
+	return c.PurgeCtx(context.Background(), collection, filter)
+}
+
+func (c *Client) PurgeCtx(ctx context.Context, collection string, filter interface{}) (*mongo.DeleteResult, error) {
+	p := c.policy(collection)
+	if !p.softDelete() {
+		return &mongo.DeleteResult{}, nil
+	}
+	ctx, cancel := withTimeout(ctx, c.timeout)
+	defer cancel()
+	return c.db.Collection(collection).DeleteMany(ctx, p.deletedFilter(filter))
+}
+
+func (c *Client) softDeleteCtx(ctx context.Context, p *CollectionPolicy, collection string, filter interface{}, many bool) (*mongo.DeleteResult, error) {
+	ctx, cancel := withTimeout(ctx, c.timeout)
+	defer cancel()
+	coll := c.db.Collection(collection)
+	update := p.deleteUpdate(ctx).Document()
+	var res *mongo.UpdateResult
+	var err error
+	if many {
+		res, err = coll.UpdateMany(ctx, p.activeFilter(filter), update)
+	} else {
+		res, err = coll.UpdateOne(ctx, p.activeFilter(filter), update)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &mongo.DeleteResult{DeletedCount: res.ModifiedCount}, nil
+}
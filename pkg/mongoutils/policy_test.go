@@ -0,0 +1,265 @@
+package mongoutils
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/sandrolain/go-utilities/pkg/crudutils"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+type audited struct {
+	ID        string     `bson:"_id"`
+	Name      string     `bson:"name"`
+	CreatedAt time.Time  `bson:"createdAt"`
+	UpdatedAt time.Time  `bson:"updatedAt"`
+	DeletedAt *time.Time `bson:"deletedAt"`
+	CreatedBy string     `bson:"createdBy"`
+}
+
+func TestCollectionPolicy(t *testing.T) {
+	client := newTestClient(t)
+	client.SetCollectionPolicy("policy", DefaultCollectionPolicy())
+	ctx := WithActor(context.Background(), "tester")
+
+	if _, err := client.InsertOneCtx(ctx, "policy", audited{ID: "p", Name: "first"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.UpdateOneByIdCtx(ctx, "policy", "p", bson.M{"name": "second"}); err != nil {
+		t.Fatal(err)
+	}
+
+	var doc audited
+	ok, err := client.FindOneById("policy", "p", &doc)
+	if err != nil || !ok {
+		t.Fatalf("document should exist: %v", err)
+	}
+	assert.Equal(t, "second", doc.Name)
+	assert.Equal(t, "tester", doc.CreatedBy)
+	assert.False(t, doc.CreatedAt.IsZero())
+	assert.False(t, doc.UpdatedAt.Before(doc.CreatedAt))
+
+	res, err := client.DeleteOneById("policy", "p")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, int64(1), res.DeletedCount)
+
+	ok, err = client.FindOneById("policy", "p", &doc)
+	if err != nil || ok {
+		t.Fatalf("document should be hidden: %v", err)
+	}
+	list := []audited{}
+	if err := client.FindMany("policy", bson.M{}, nil, 0, &list); err != nil {
+		t.Fatal(err)
+	}
+	assert.Empty(t, list)
+
+	if _, err := client.RestoreById("policy", "p"); err != nil {
+		t.Fatal(err)
+	}
+	ok, err = client.FindOneById("policy", "p", &doc)
+	if err != nil || !ok {
+		t.Fatalf("document should be restored: %v", err)
+	}
+	assert.Nil(t, doc.DeletedAt)
+
+	if _, err := client.DeleteOneById("policy", "p"); err != nil {
+		t.Fatal(err)
+	}
+	purged, err := client.Purge("policy", bson.M{"_id": "p"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, int64(1), purged.DeletedCount)
+
+	client.RemoveCollectionPolicy("policy")
+	count, err := client.Coll("policy").CountDocuments(context.Background(), bson.M{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, int64(0), count)
+}
+
+func TestStampUpdate(t *testing.T) {
+	p := DefaultCollectionPolicy()
+	ctx := WithActor(context.Background(), "tester")
+
+	ops := make(bson.D, 0, 4)
+	ops = append(ops, bson.E{Key: "name", Value: "first"})
+	update := &Update{ops: bson.D{{Key: "$set", Value: ops}}}
+	stamped, err := p.stampUpdate(ctx, update, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	set := stamped.(*Update).Document().Map()["$set"].(bson.D).Map()
+	assert.Equal(t, "first", set["name"])
+	assert.Equal(t, "tester", set["updatedBy"])
+	assert.Len(t, update.Document().Map()["$set"].(bson.D), 1)
+	// the spare capacity of the caller document is left untouched
+	assert.Equal(t, bson.E{}, ops[:2][1])
+
+	plain, err := toUpdate(bson.M{"name": "second"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	stamped, err = p.stampUpdate(ctx, plain.Inc("version", 1), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc := stamped.(*Update).Document().Map()
+	assert.Equal(t, "second", doc["$set"].(bson.D).Map()["name"])
+	assert.Contains(t, doc["$set"].(bson.D).Map(), "updatedAt")
+	assert.Contains(t, doc["$setOnInsert"].(bson.D).Map(), "createdAt")
+	assert.Equal(t, bson.D{{Key: "version", Value: 1}}, doc["$inc"])
+}
+
+func TestStampUpdateDocument(t *testing.T) {
+	p := DefaultCollectionPolicy()
+	ctx := WithActor(context.Background(), "tester")
+
+	stamped, err := p.stampUpdate(ctx, audited{ID: "a", Name: "first"}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc := stamped.(*Update).Document().Map()
+	set := doc["$set"].(bson.D).Map()
+	assert.Equal(t, "first", set["name"])
+	assert.Equal(t, "tester", set["updatedBy"])
+	assert.NotContains(t, set, "createdAt")
+	assert.NotContains(t, set, "createdBy")
+	assert.NotContains(t, set, "deletedAt")
+	assert.Len(t, doc["$setOnInsert"].(bson.D), 2)
+
+	p.Audit = false
+	stamped, err = p.stampUpdate(ctx, NewUpdate().Set("deletedAt", nil), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Empty(t, stamped.(*Update).Document())
+}
+
+func TestCollectionPolicyDocuments(t *testing.T) {
+	client := newTestClient(t)
+	client.SetCollectionPolicy("policy_docs", DefaultCollectionPolicy())
+	ctx := WithActor(context.Background(), "tester")
+
+	if _, err := client.UpsertOneByIdCtx(ctx, "policy_docs", "d", audited{ID: "d", Name: "first"}); err != nil {
+		t.Fatal(err)
+	}
+	var created audited
+	if _, err := client.FindOneById("policy_docs", "d", &created); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "tester", created.CreatedBy)
+	assert.False(t, created.CreatedAt.IsZero())
+
+	if _, err := client.UpdateOneByIdCtx(context.Background(), "policy_docs", "d", audited{ID: "d", Name: "second"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.ReplaceOneById("policy_docs", "d", audited{ID: "d", Name: "third"}); err != nil {
+		t.Fatal(err)
+	}
+	var doc audited
+	if _, err := client.FindOneById("policy_docs", "d", &doc); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "third", doc.Name)
+	assert.Equal(t, "tester", doc.CreatedBy)
+	assert.True(t, created.CreatedAt.Equal(doc.CreatedAt))
+	assert.False(t, doc.UpdatedAt.Before(created.UpdatedAt))
+
+	if _, err := client.DeleteOneById("policy_docs", "d"); err != nil {
+		t.Fatal(err)
+	}
+	res, err := client.ReplaceOneById("policy_docs", "d", audited{ID: "d", Name: "revived"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, int64(0), res.MatchedCount)
+
+	if _, err := client.RestoreCtx(ctx, "policy_docs", bson.M{"_id": "d"}); err != nil {
+		t.Fatal(err)
+	}
+	var restored bson.M
+	if _, err := client.FindOneById("policy_docs", "d", &restored); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "tester", restored["updatedBy"])
+}
+
+func TestBulkWriterPolicy(t *testing.T) {
+	client := newTestClient(t)
+	client.SetCollectionPolicy("policy_bulk", DefaultCollectionPolicy())
+	w := client.NewBulkWriter("policy_bulk", BulkOptions{Actor: "tester"})
+
+	w.Insert(audited{ID: "b1", Name: "one"})
+	w.Insert(audited{ID: "b2", Name: "two"})
+	w.UpdateOne(bson.M{"_id": "b1"}, bson.M{"name": "first"})
+	w.DeleteOne(bson.M{"_id": "b2"})
+	w.UpdateMany(bson.M{}, NewUpdate().Set("tag", "x"))
+	if _, err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	var doc bson.M
+	if _, err := client.FindOneById("policy_bulk", "b1", &doc); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "first", doc["name"])
+	assert.Equal(t, "x", doc["tag"])
+	assert.Equal(t, "tester", doc["createdBy"])
+	assert.Equal(t, "tester", doc["updatedBy"])
+
+	ok, err := client.FindOneById("policy_bulk", "b2", &doc)
+	assert.Nil(t, err)
+	assert.False(t, ok)
+	count, err := client.Coll("policy_bulk").CountDocuments(context.Background(), bson.M{"_id": "b2", "tag": bson.M{"$exists": false}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, int64(1), count)
+}
+
+func TestVersionedCollectionPolicy(t *testing.T) {
+	client := newTestClient(t)
+	client.SetCollectionPolicy("versioned_policy", DefaultCollectionPolicy())
+	vc := client.Versioned("versioned_policy", "")
+
+	if _, err := client.InsertOne("versioned_policy", bson.M{"_id": "v", "count": 0}); err != nil {
+		t.Fatal(err)
+	}
+	if err := vc.UpdateOneById("v", 0, bson.M{"count": 1}); err != nil {
+		t.Fatal(err)
+	}
+	if err := vc.UpdateOneById("v", 1, NewUpdate().Inc("count", 1)); err != nil {
+		t.Fatal(err)
+	}
+
+	var doc bson.M
+	if _, err := client.FindOneById("versioned_policy", "v", &doc); err != nil {
+		t.Fatal(err)
+	}
+	assert.EqualValues(t, 2, doc["count"])
+	assert.EqualValues(t, 2, doc["version"])
+	assert.Contains(t, doc, "updatedAt")
+
+	if _, err := client.DeleteOneById("versioned_policy", "v"); err != nil {
+		t.Fatal(err)
+	}
+	repo := NewRepository[bson.M](client, "versioned_policy")
+	_, err := repo.Get("v")
+	assert.True(t, crudutils.IsNotFound(err))
+	items, err := Aggregate[bson.M](client, "versioned_policy", NewPipeline())
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Empty(t, items)
+	page, err := FindPage[bson.M](client, "versioned_policy", PageRequest{Size: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Empty(t, page.Items)
+}
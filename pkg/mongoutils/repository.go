@@ -0,0 +1,114 @@
+package mongoutils
+
+import (
+	"fmt"
+
+	"github.com/sandrolain/go-utilities/pkg/crudutils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type Repository[T interface{}] struct {
+	client     *Client
+	collection string
+}
+
+func NewRepository[T interface{}](client *Client, collection string) *Repository[T] {
+	return &Repository[T]{
+		client:     client,
+		collection: collection,
+	}
+}
+
+func (r *Repository[T]) Coll() *mongo.Collection {
+	return r.client.Coll(r.collection)
+}
+
+func (r *Repository[T]) Get(id interface{}) (T, error) {
+	return r.FindOne(bson.M{"_id": id})
+}
+
+func (r *Repository[T]) FindOne(filter interface{}) (res T, err error) {
+	ctx, cancel := createContext(r.client.timeout)
+	defer cancel()
+	err = r.Coll().FindOne(ctx, r.policy().activeFilter(filter)).Decode(&res)
+	err = notFoundError(err, filter)
+	return
+}
+
+func (r *Repository[T]) List(filter interface{}, opts *options.FindOptions) ([]T, error) {
+	ctx, cancel := createContext(r.client.timeout)
+	defer cancel()
+	if filter == nil {
+		filter = bson.M{}
+	}
+	cursor, err := r.Coll().Find(ctx, r.policy().activeFilter(filter), opts)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]T, 0)
+	if err := cursor.All(ctx, &res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func (r *Repository[T]) Create(v T) (interface{}, error) {
+	ctx, cancel := createContext(r.client.timeout)
+	defer cancel()
+	doc, err := r.policy().stampInsert(ctx, v)
+	if err != nil {
+		return nil, err
+	}
+	res, err := r.Coll().InsertOne(ctx, doc)
+	if err != nil {
+		return nil, err
+	}
+	return res.InsertedID, nil
+}
+
+func (r *Repository[T]) Update(id interface{}, update interface{}) (res T, err error) {
+	ctx, cancel := createContext(r.client.timeout)
+	defer cancel()
+	filter := bson.M{"_id": id}
+	p := r.policy()
+	if update, err = p.stampUpdate(ctx, update, false); err != nil {
+		return
+	}
+	doc, arrayFilters := updateDocument(update)
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	if arrayFilters != nil {
+		opts.SetArrayFilters(*arrayFilters)
+	}
+	err = r.Coll().FindOneAndUpdate(ctx, p.activeFilter(filter), doc, opts).Decode(&res)
+	err = notFoundError(err, filter)
+	return
+}
+
+func (r *Repository[T]) Delete(id interface{}) (res T, err error) {
+	ctx, cancel := createContext(r.client.timeout)
+	defer cancel()
+	filter := bson.M{"_id": id}
+	if p := r.policy(); p.softDelete() {
+		update := p.deleteUpdate(ctx).Document()
+		err = r.Coll().FindOneAndUpdate(ctx, p.activeFilter(filter), update).Decode(&res)
+	} else {
+		err = r.Coll().FindOneAndDelete(ctx, filter).Decode(&res)
+	}
+	err = notFoundError(err, filter)
+	return
+}
+
+// policy returns the collection policy set on the client, which the
+// repository applies like the Client methods.
+func (r *Repository[T]) policy() *CollectionPolicy {
+	return r.client.policy(r.collection)
+}
+
+func notFoundError(err error, filter interface{}) error {
+	if err == mongo.ErrNoDocuments {
+		return crudutils.NotFound(fmt.Sprintf("%v", filter))
+	}
+	return err
+}
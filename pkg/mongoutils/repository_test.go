@@ -0,0 +1,65 @@
+package mongoutils
+
+import (
+	"testing"
+	"time"
+
+	"github.com/sandrolain/go-utilities/pkg/crudutils"
+	"github.com/sandrolain/go-utilities/pkg/testmongoutils"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+type TestDoc struct {
+	ID   string `bson:"_id"`
+	Name string `bson:"name"`
+	Age  int    `bson:"age"`
+}
+
+func newTestClient(t *testing.T) *Client {
+	client, err := NewClient(testmongoutils.GetMockServerURI(), "test", 10*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(client.Close)
+	return client
+}
+
+func TestRepository(t *testing.T) {
+	client := newTestClient(t)
+	repo := NewRepository[TestDoc](client, "repository")
+
+	id, err := repo.Create(TestDoc{ID: "a", Name: "Alice", Age: 30})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "a", id)
+
+	doc, err := repo.Get("a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "Alice", doc.Name)
+
+	doc, err = repo.Update("a", bson.M{"age": 31})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 31, doc.Age)
+
+	list, err := repo.List(bson.M{"name": "Alice"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Len(t, list, 1)
+
+	_, err = repo.Delete("a")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = repo.Get("a")
+	if !crudutils.IsNotFound(err) {
+		t.Fatalf("Error is not NotFound: %v", err)
+	}
+}
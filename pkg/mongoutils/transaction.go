@@ -0,0 +1,134 @@
+package mongoutils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var ErrTransactionsNotSupported = errors.New("MongoDB transactions require a replica set or sharded cluster")
+
+const illegalOperationCode = 20
+
+type Tx struct {
+	client *Client
+	ctx    mongo.SessionContext
+}
+
+func (c *Client) WithTransaction(fn func(tx *Tx) error) error {
+	return c.WithTransactionCtx(context.Background(), fn)
+}
+
+// WithTransactionCtx runs fn inside a session transaction. The driver retries
+// the whole callback on TransientTransactionError and the commit on
+// UnknownTransactionCommitResult until ctx is done or its own 120s limit is hit.
+func (c *Client) WithTransactionCtx(ctx context.Context, fn func(tx *Tx) error) error {
+	session, err := c.client.StartSession()
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(context.Background())
+	_, err = session.WithTransaction(ctx, func(sc mongo.SessionContext) (interface{}, error) {
+		return nil, fn(&Tx{client: c, ctx: sc})
+	})
+	return transactionError(err)
+}
+
+func transactionError(err error) error {
+	if err == nil {
+		return nil
+	}
+	var cmdErr mongo.CommandError
+	if errors.As(err, &cmdErr) && cmdErr.Code == illegalOperationCode && strings.Contains(cmdErr.Message, "Transaction numbers") {
+		return fmt.Errorf("%w: %v", ErrTransactionsNotSupported, err)
+	}
+	return err
+}
+
+func (tx *Tx) Context() context.Context {
+	return tx.ctx
+}
+
+func (tx *Tx) FindOne(collection string, filter interface{}, v interface{}) (bool, error) {
+	return tx.client.FindOneCtx(tx.ctx, collection, filter, v)
+}
+
+func (tx *Tx) FindOneById(collection string, id interface{}, v interface{}) (bool, error) {
+	return tx.client.FindOneByIdCtx(tx.ctx, collection, id, v)
+}
+
+func (tx *Tx) FindOneByField(collection string, field string, value interface{}, v interface{}) (bool, error) {
+	return tx.client.FindOneByFieldCtx(tx.ctx, collection, field, value, v)
+}
+
+func (tx *Tx) FindMany(collection string, filter interface{}, sort interface{}, limit int64, v interface{}) error {
+	return tx.client.FindManyCtx(tx.ctx, collection, filter, sort, limit, v)
+}
+
+func (tx *Tx) FindManyByField(collection string, field string, value interface{}, limit int64, v interface{}) error {
+	return tx.client.FindManyByFieldCtx(tx.ctx, collection, field, value, limit, v)
+}
+
+func (tx *Tx) InsertOne(collection string, v interface{}) (*mongo.InsertOneResult, error) {
+	return tx.client.InsertOneCtx(tx.ctx, collection, v)
+}
+
+func (tx *Tx) InsertMany(collection string, v []interface{}) (*mongo.InsertManyResult, error) {
+	return tx.client.InsertManyCtx(tx.ctx, collection, v)
+}
+
+func (tx *Tx) UpdateOne(collection string, filter interface{}, update interface{}) (*mongo.UpdateResult, error) {
+	return tx.client.UpdateOneCtx(tx.ctx, collection, filter, update)
+}
+
+func (tx *Tx) UpdateOneByField(collection string, field string, value interface{}, update interface{}) (*mongo.UpdateResult, error) {
+	return tx.client.UpdateOneByFieldCtx(tx.ctx, collection, field, value, update)
+}
+
+func (tx *Tx) UpdateOneById(collection string, id interface{}, update interface{}) (*mongo.UpdateResult, error) {
+	return tx.client.UpdateOneByIdCtx(tx.ctx, collection, id, update)
+}
+
+func (tx *Tx) UpdateMany(collection string, filter interface{}, update interface{}) (*mongo.UpdateResult, error) {
+	return tx.client.UpdateManyCtx(tx.ctx, collection, filter, update)
+}
+
+func (tx *Tx) FindOneAndUpdate(collection string, filter interface{}, update interface{}, returnDocument options.ReturnDocument, v interface{}) (bool, error) {
+	return tx.client.FindOneAndUpdateCtx(tx.ctx, collection, filter, update, returnDocument, v)
+}
+
+func (tx *Tx) ReplaceOne(collection string, filter interface{}, replacement interface{}) (*mongo.UpdateResult, error) {
+	return tx.client.ReplaceOneCtx(tx.ctx, collection, filter, replacement)
+}
+
+func (tx *Tx) UpsertOne(collection string, filter interface{}, update interface{}) (*mongo.UpdateResult, error) {
+	return tx.client.UpsertOneCtx(tx.ctx, collection, filter, update)
+}
+
+func (tx *Tx) UpsertOneByField(collection string, field string, value interface{}, update interface{}) (*mongo.UpdateResult, error) {
+	return tx.client.UpsertOneByFieldCtx(tx.ctx, collection, field, value, update)
+}
+
+func (tx *Tx) UpsertOneById(collection string, id interface{}, update interface{}) (*mongo.UpdateResult, error) {
+	return tx.client.UpsertOneByIdCtx(tx.ctx, collection, id, update)
+}
+
+func (tx *Tx) DeleteOne(collection string, filter interface{}) (*mongo.DeleteResult, error) {
+	return tx.client.DeleteOneCtx(tx.ctx, collection, filter)
+}
+
+func (tx *Tx) DeleteOneByField(collection string, field string, value interface{}) (*mongo.DeleteResult, error) {
+	return tx.client.DeleteOneByFieldCtx(tx.ctx, collection, field, value)
+}
+
+func (tx *Tx) DeleteOneById(collection string, id interface{}) (*mongo.DeleteResult, error) {
+	return tx.client.DeleteOneByIdCtx(tx.ctx, collection, id)
+}
+
+func (tx *Tx) DeleteMany(collection string, filter interface{}) (*mongo.DeleteResult, error) {
+	return tx.client.DeleteManyCtx(tx.ctx, collection, filter)
+}
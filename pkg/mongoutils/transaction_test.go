@@ -0,0 +1,20 @@
+package mongoutils
+
+import (
+	"errors"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestWithTransactionStandalone(t *testing.T) {
+	client := newTestClient(t)
+
+	err := client.WithTransaction(func(tx *Tx) error {
+		_, err := tx.InsertOne("transaction", bson.M{"_id": "tx"})
+		return err
+	})
+	if !errors.Is(err, ErrTransactionsNotSupported) {
+		t.Fatalf("Error is not ErrTransactionsNotSupported: %v", err)
+	}
+}
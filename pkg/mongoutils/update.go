@@ -0,0 +1,230 @@
+package mongoutils
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type Update struct {
+	// ops holds the document of every operator as a bson.D
+	ops          bson.D
+	arrayFilters []interface{}
+}
+
+func NewUpdate() *Update {
+	return &Update{
+		ops: bson.D{},
+	}
+}
+
+func (u *Update) add(op string, field string, value interface{}) *Update {
+	for i, e := range u.ops {
+		if doc, ok := e.Value.(bson.D); ok && e.Key == op {
+			u.ops[i].Value = append(doc, bson.E{Key: field, Value: value})
+			return u
+		}
+	}
+	u.ops = append(u.ops, bson.E{Key: op, Value: bson.D{{Key: field, Value: value}}})
+	return u
+}
+
+// remove deletes fields from the document of op, dropping the operator once
+// it is empty.
+func (u *Update) remove(op string, fields ...string) {
+	for i, e := range u.ops {
+		doc, ok := e.Value.(bson.D)
+		if !ok || e.Key != op {
+			continue
+		}
+		if doc = removeFields(doc, fields...); len(doc) > 0 {
+			u.ops[i].Value = doc
+		} else {
+			u.ops = append(u.ops[:i], u.ops[i+1:]...)
+		}
+		return
+	}
+}
+
+func (u *Update) Set(field string, value interface{}) *Update {
+	return u.add("$set", field, value)
+}
+
+func (u *Update) SetOnInsert(field string, value interface{}) *Update {
+	return u.add("$setOnInsert", field, value)
+}
+
+func (u *Update) Unset(fields ...string) *Update {
+	for _, field := range fields {
+		u.add("$unset", field, "")
+	}
+	return u
+}
+
+func (u *Update) Inc(field string, value interface{}) *Update {
+	return u.add("$inc", field, value)
+}
+
+func (u *Update) Push(field string, value interface{}) *Update {
+	return u.add("$push", field, value)
+}
+
+func (u *Update) PushEach(field string, values ...interface{}) *Update {
+	return u.add("$push", field, bson.D{{Key: "$each", Value: values}})
+}
+
+func (u *Update) AddToSet(field string, value interface{}) *Update {
+	return u.add("$addToSet", field, value)
+}
+
+func (u *Update) AddToSetEach(field string, values ...interface{}) *Update {
+	return u.add("$addToSet", field, bson.D{{Key: "$each", Value: values}})
+}
+
+func (u *Update) Pull(field string, condition interface{}) *Update {
+	return u.add("$pull", field, condition)
+}
+
+func (u *Update) CurrentDate(field string) *Update {
+	return u.add("$currentDate", field, true)
+}
+
+func (u *Update) CurrentTimestamp(field string) *Update {
+	return u.add("$currentDate", field, bson.D{{Key: "$type", Value: "timestamp"}})
+}
+
+func (u *Update) ArrayFilter(filter interface{}) *Update {
+	u.arrayFilters = append(u.arrayFilters, filter)
+	return u
+}
+
+// clone returns a copy of u that can be extended without modifying the
+// operator documents of u.
+func (u *Update) clone() *Update {
+	res := &Update{
+		ops:          make(bson.D, len(u.ops)),
+		arrayFilters: u.arrayFilters,
+	}
+	for i, e := range u.ops {
+		doc, _ := e.Value.(bson.D)
+		res.ops[i] = bson.E{Key: e.Key, Value: append(bson.D{}, doc...)}
+	}
+	return res
+}
+
+// toUpdate returns a copy of update as an *Update, wrapping plain values in
+// $set like updateDocument.
+func toUpdate(update interface{}) (*Update, error) {
+	if u, ok := update.(*Update); ok {
+		return u.clone(), nil
+	}
+	doc, err := toDocument(update)
+	if err != nil {
+		return nil, err
+	}
+	return &Update{ops: bson.D{{Key: "$set", Value: doc}}}, nil
+}
+
+func (u *Update) Document() bson.D {
+	return u.ops
+}
+
+func (u *Update) ArrayFilters() []interface{} {
+	return u.arrayFilters
+}
+
+// updateDocument keeps the historical behaviour of wrapping plain values in
+// $set, while an *Update is sent with its own operators and array filters.
+func updateDocument(update interface{}) (interface{}, *options.ArrayFilters) {
+	u, ok := update.(*Update)
+	if !ok {
+		return bson.M{"$set": update}, nil
+	}
+	if len(u.arrayFilters) == 0 {
+		return u.Document(), nil
+	}
+	return u.Document(), &options.ArrayFilters{Filters: u.arrayFilters}
+}
+
+func (c *Client) UpdateMany(collection string, filter interface{}, update interface{}) (*mongo.UpdateResult, error) {
+	return c.UpdateManyCtx(context.Background(), collection, filter, update)
+}
+
+func (c *Client) UpdateManyCtx(ctx context.Context, collection string, filter interface{}, update interface{}) (*mongo.UpdateResult, error) {
+	p := c.policy(collection)
+	filter = p.activeFilter(filter)
+	update, err := p.stampUpdate(ctx, update, false)
+	if err != nil {
+		return nil, err
+	}
+	doc, arrayFilters := updateDocument(update)
+	opts := options.Update()
+	if arrayFilters != nil {
+		opts.SetArrayFilters(*arrayFilters)
+	}
+	ctx, cancel := withTimeout(ctx, c.timeout)
+	defer cancel()
+	return c.db.Collection(collection).UpdateMany(ctx, filter, doc, opts)
+}
+
+func (c *Client) FindOneAndUpdate(collection string, filter interface{}, update interface{}, returnDocument options.ReturnDocument, v interface{}) (bool, error) {
+	return c.FindOneAndUpdateCtx(context.Background(), collection, filter, update, returnDocument, v)
+}
+
+func (c *Client) FindOneAndUpdateCtx(ctx context.Context, collection string, filter interface{}, update interface{}, returnDocument options.ReturnDocument, v interface{}) (bool, error) {
+	p := c.policy(collection)
+	filter = p.activeFilter(filter)
+	update, err := p.stampUpdate(ctx, update, false)
+	if err != nil {
+		return false, err
+	}
+	doc, arrayFilters := updateDocument(update)
+	opts := options.FindOneAndUpdate().SetReturnDocument(returnDocument)
+	if arrayFilters != nil {
+		opts.SetArrayFilters(*arrayFilters)
+	}
+	ctx, cancel := withTimeout(ctx, c.timeout)
+	defer cancel()
+	err = c.db.Collection(collection).FindOneAndUpdate(ctx, filter, doc, opts).Decode(v)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+func (c *Client) ReplaceOne(collection string, filter interface{}, replacement interface{}) (*mongo.UpdateResult, error) {
+	return c.ReplaceOneCtx(context.Background(), collection, filter, replacement)
+}
+
+// ReplaceOneCtx replaces a document, keeping its creation audit fields on
+// collections with an audit policy.
+func (c *Client) ReplaceOneCtx(ctx context.Context, collection string, filter interface{}, replacement interface{}) (*mongo.UpdateResult, error) {
+	p := c.policy(collection)
+	filter = p.activeFilter(filter)
+	coll := c.db.Collection(collection)
+	if !p.audit() {
+		ctx, cancel := withTimeout(ctx, c.timeout)
+		defer cancel()
+		return coll.ReplaceOne(ctx, filter, replacement)
+	}
+	pipeline, err := p.stampReplace(ctx, replacement)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := withTimeout(ctx, c.timeout)
+	defer cancel()
+	return coll.UpdateOne(ctx, filter, pipeline)
+}
+
+func (c *Client) ReplaceOneById(collection string, id interface{}, replacement interface{}) (*mongo.UpdateResult, error) {
+	return c.ReplaceOneByIdCtx(context.Background(), collection, id, replacement)
+}
+
+func (c *Client) ReplaceOneByIdCtx(ctx context.Context, collection string, id interface{}, replacement interface{}) (*mongo.UpdateResult, error) {
+	return c.ReplaceOneCtx(ctx, collection, bson.M{"_id": id}, replacement)
+}
@@ -0,0 +1,66 @@
+package mongoutils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func TestUpdateBuilder(t *testing.T) {
+	u := NewUpdate().
+		Set("name", "x").
+		Inc("count", 1).
+		Set("active", true).
+		Unset("legacy").
+		ArrayFilter(bson.M{"e.qty": bson.M{"$lt": 0}})
+
+	doc, arrayFilters := updateDocument(u)
+	assert.Equal(t, bson.D{
+		{Key: "$set", Value: bson.D{{Key: "name", Value: "x"}, {Key: "active", Value: true}}},
+		{Key: "$inc", Value: bson.D{{Key: "count", Value: 1}}},
+		{Key: "$unset", Value: bson.D{{Key: "legacy", Value: ""}}},
+	}, doc)
+	assert.Len(t, arrayFilters.Filters, 1)
+
+	doc, arrayFilters = updateDocument(bson.M{"name": "x"})
+	assert.Equal(t, bson.M{"$set": bson.M{"name": "x"}}, doc)
+	assert.Nil(t, arrayFilters)
+}
+
+func TestUpdateOperators(t *testing.T) {
+	client := newTestClient(t)
+
+	type counter struct {
+		ID    string   `bson:"_id"`
+		Count int      `bson:"count"`
+		Tags  []string `bson:"tags"`
+		Owner string   `bson:"owner"`
+	}
+
+	update := NewUpdate().Inc("count", 1).AddToSet("tags", "a").SetOnInsert("owner", "me")
+	if _, err := client.UpsertOneById("update", "c", update); err != nil {
+		t.Fatal(err)
+	}
+
+	var before counter
+	ok, err := client.FindOneAndUpdate("update", bson.M{"_id": "c"}, NewUpdate().Inc("count", 2).Push("tags", "b"), options.Before, &before)
+	if err != nil || !ok {
+		t.Fatalf("document should be updated: %v", err)
+	}
+	assert.Equal(t, counter{"c", 1, []string{"a"}, "me"}, before)
+
+	if _, err := client.UpdateMany("update", bson.M{}, NewUpdate().Pull("tags", "a")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.ReplaceOneById("update", "c", counter{ID: "c", Count: 10}); err != nil {
+		t.Fatal(err)
+	}
+
+	var after counter
+	if _, err := client.FindOneById("update", "c", &after); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, counter{ID: "c", Count: 10}, after)
+}
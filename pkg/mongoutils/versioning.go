@@ -0,0 +1,106 @@
+package mongoutils
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/sandrolain/go-utilities/pkg/crudutils"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+const DefaultVersionField = "version"
+
+type VersionedCollection struct {
+	client     *Client
+	collection string
+	field      string
+}
+
+func (c *Client) Versioned(collection string, field string) *VersionedCollection {
+	if field == "" {
+		field = DefaultVersionField
+	}
+	return &VersionedCollection{
+		client:     c,
+		collection: collection,
+		field:      field,
+	}
+}
+
+func (v *VersionedCollection) UpdateOneById(id interface{}, version int64, update interface{}) error {
+	return v.UpdateOneByIdCtx(context.Background(), id, version, update)
+}
+
+// UpdateOneByIdCtx applies update only if the stored version equals version,
+// incrementing it in the same operation. Version 0 also matches documents
+// without the version field, so existing data can opt in lazily.
+func (v *VersionedCollection) UpdateOneByIdCtx(ctx context.Context, id interface{}, version int64, update interface{}) error {
+	var expected interface{} = version
+	if version == 0 {
+		expected = bson.M{"$in": bson.A{0, nil}}
+	}
+	filter := bson.M{"_id": id, v.field: expected}
+	versioned, err := toUpdate(update)
+	if err != nil {
+		return err
+	}
+	// a whole document carries the loaded version, which $inc replaces
+	versioned.remove("$set", v.field)
+	res, err := v.client.UpdateOneCtx(ctx, v.collection, filter, versioned.Inc(v.field, 1))
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount > 0 {
+		return nil
+	}
+	var current bson.Raw
+	ok, err := v.client.FindOneByIdCtx(ctx, v.collection, id, &current)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return crudutils.NotFound(fmt.Sprintf("%v", id))
+	}
+	return crudutils.Conflict(fmt.Sprintf("%v version %v", id, version))
+}
+
+func (v *VersionedCollection) version(doc bson.Raw) int64 {
+	val, err := doc.LookupErr(v.field)
+	if err != nil {
+		return 0
+	}
+	if i, ok := val.AsInt64OK(); ok {
+		return i
+	}
+	return 0
+}
+
+// UpdateVersioned loads the document, passes it to mutate to obtain the update
+// and applies it with the loaded version, reloading and retrying up to attempts
+// times while the update conflicts with a concurrent change.
+func UpdateVersioned[T interface{}](ctx context.Context, v *VersionedCollection, id interface{}, attempts int, mutate func(doc T) (interface{}, error)) error {
+	var err error
+	for i := 0; i < attempts; i++ {
+		var raw bson.Raw
+		ok, findErr := v.client.FindOneByIdCtx(ctx, v.collection, id, &raw)
+		if findErr != nil {
+			return findErr
+		}
+		if !ok {
+			return crudutils.NotFound(fmt.Sprintf("%v", id))
+		}
+		var doc T
+		if err := bson.Unmarshal(raw, &doc); err != nil {
+			return err
+		}
+		update, mutateErr := mutate(doc)
+		if mutateErr != nil {
+			return mutateErr
+		}
+		err = v.UpdateOneByIdCtx(ctx, id, v.version(raw), update)
+		if !crudutils.IsConflict(err) {
+			return err
+		}
+	}
+	return err
+}
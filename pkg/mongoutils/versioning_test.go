@@ -0,0 +1,86 @@
+package mongoutils
+
+import (
+	"context"
+	"testing"
+
+	"github.com/sandrolain/go-utilities/pkg/crudutils"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestVersionedCollection(t *testing.T) {
+	client := newTestClient(t)
+	vc := client.Versioned("versioned", "")
+
+	type doc struct {
+		ID      string `bson:"_id"`
+		Count   int    `bson:"count"`
+		Version int64  `bson:"version"`
+	}
+	if _, err := client.InsertOne("versioned", bson.M{"_id": "v", "count": 0}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := vc.UpdateOneById("v", 0, bson.M{"count": 1}); err != nil {
+		t.Fatal(err)
+	}
+	err := vc.UpdateOneById("v", 0, bson.M{"count": 2})
+	if !crudutils.IsConflict(err) {
+		t.Fatalf("Error is not Conflict: %v", err)
+	}
+	err = vc.UpdateOneById("missing", 0, bson.M{"count": 2})
+	if !crudutils.IsNotFound(err) {
+		t.Fatalf("Error is not NotFound: %v", err)
+	}
+
+	attempts := 0
+	err = UpdateVersioned(context.Background(), vc, "v", 3, func(d doc) (interface{}, error) {
+		attempts++
+		if attempts == 1 {
+			if err := vc.UpdateOneById("v", d.Version, bson.M{"count": 100}); err != nil {
+				return nil, err
+			}
+		}
+		return NewUpdate().Inc("count", 1), nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 2, attempts)
+
+	var res doc
+	if _, err := client.FindOneById("versioned", "v", &res); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, doc{"v", 101, 3}, res)
+}
+
+func TestUpdateVersionedDocument(t *testing.T) {
+	client := newTestClient(t)
+	vc := client.Versioned("versioned_doc", "")
+
+	type doc struct {
+		ID      string `bson:"_id"`
+		Count   int    `bson:"count"`
+		Version int64  `bson:"version"`
+	}
+	if _, err := client.InsertOne("versioned_doc", doc{ID: "v"}); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		err := UpdateVersioned(context.Background(), vc, "v", 1, func(d doc) (interface{}, error) {
+			d.Count++
+			return d, nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var res doc
+	if _, err := client.FindOneById("versioned_doc", "v", &res); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, doc{"v", 2, 2}, res)
+}
@@ -0,0 +1,250 @@
+package mongoutils
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/sandrolain/go-utilities/pkg/redisutils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const DefaultWatchRetryDelay = time.Second
+
+type ChangeNamespace struct {
+	Database   string `bson:"db"`
+	Collection string `bson:"coll"`
+}
+
+type UpdateDescription struct {
+	UpdatedFields bson.M   `bson:"updatedFields"`
+	RemovedFields []string `bson:"removedFields"`
+}
+
+type ChangeEvent[T interface{}] struct {
+	ResumeToken       bson.Raw            `bson:"_id"`
+	OperationType     string              `bson:"operationType"`
+	Namespace         ChangeNamespace     `bson:"ns"`
+	DocumentKey       bson.M              `bson:"documentKey"`
+	FullDocument      *T                  `bson:"fullDocument"`
+	UpdateDescription *UpdateDescription  `bson:"updateDescription"`
+	ClusterTime       primitive.Timestamp `bson:"clusterTime"`
+}
+
+type ResumeTokenStore interface {
+	LoadToken(ctx context.Context, name string) ([]byte, error)
+	SaveToken(ctx context.Context, name string, token []byte) error
+}
+
+type WatchOptions struct {
+	Name         string
+	Pipeline     mongo.Pipeline
+	FullDocument bool
+	Store        ResumeTokenStore
+	RetryDelay   time.Duration
+}
+
+// Watch opens a change stream on collection, or on the whole database when
+// collection is empty, and calls handler for every event until ctx is done or
+// handler fails. The stream is reopened from the last token after disconnects.
+func Watch[T interface{}](ctx context.Context, c *Client, collection string, opts WatchOptions, handler func(ChangeEvent[T]) error) error {
+	if opts.Store != nil && opts.Name == "" {
+		return errors.New("empty change stream name for resume token store")
+	}
+	delay := opts.RetryDelay
+	if delay <= 0 {
+		delay = DefaultWatchRetryDelay
+	}
+
+	var token bson.Raw
+	if opts.Store != nil {
+		b, err := opts.Store.LoadToken(ctx, opts.Name)
+		if err != nil {
+			return err
+		}
+		if len(b) > 0 {
+			token = bson.Raw(b)
+		}
+	}
+
+	for {
+		err := watchStream(ctx, c, collection, opts, token, func(ev ChangeEvent[T], next bson.Raw) error {
+			if err := handler(ev); err != nil {
+				return &handlerError{err}
+			}
+			token = next
+			if opts.Store != nil {
+				if err := opts.Store.SaveToken(ctx, opts.Name, next); err != nil {
+					return &handlerError{err}
+				}
+			}
+			return nil
+		})
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		var hErr *handlerError
+		if errors.As(err, &hErr) {
+			return hErr.err
+		}
+		if err != nil && !isResumableWatchError(err) {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+}
+
+func Subscribe[T interface{}](ctx context.Context, c *Client, collection string, opts WatchOptions) (<-chan ChangeEvent[T], <-chan error) {
+	events := make(chan ChangeEvent[T])
+	errs := make(chan error, 1)
+	go func() {
+		defer close(events)
+		defer close(errs)
+		err := Watch(ctx, c, collection, opts, func(ev ChangeEvent[T]) error {
+			select {
+			case events <- ev:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		})
+		if err != nil && err != context.Canceled {
+			errs <- err
+		}
+	}()
+	return events, errs
+}
+
+func watchStream[T interface{}](ctx context.Context, c *Client, collection string, opts WatchOptions, token bson.Raw, fn func(ChangeEvent[T], bson.Raw) error) error {
+	streamOpts := options.ChangeStream()
+	if opts.FullDocument {
+		streamOpts.SetFullDocument(options.UpdateLookup)
+	}
+	if token != nil {
+		streamOpts.SetStartAfter(token)
+	}
+	pipeline := opts.Pipeline
+	if pipeline == nil {
+		pipeline = mongo.Pipeline{}
+	}
+
+	openCtx, cancel := withTimeout(ctx, c.timeout)
+	var stream *mongo.ChangeStream
+	var err error
+	if collection == "" {
+		stream, err = c.db.Watch(openCtx, pipeline, streamOpts)
+	} else {
+		stream, err = c.Coll(collection).Watch(openCtx, pipeline, streamOpts)
+	}
+	cancel()
+	if err != nil {
+		return err
+	}
+	defer stream.Close(context.Background())
+
+	for stream.Next(ctx) {
+		var ev ChangeEvent[T]
+		if err := stream.Decode(&ev); err != nil {
+			return &handlerError{err}
+		}
+		if err := fn(ev, stream.ResumeToken()); err != nil {
+			return err
+		}
+	}
+	return stream.Err()
+}
+
+type handlerError struct {
+	err error
+}
+
+func (e *handlerError) Error() string {
+	return e.err.Error()
+}
+
+// isResumableWatchError retries network errors and server errors labelled as
+// resumable. Context, decoding, authentication and configuration errors are
+// returned to the caller.
+func isResumableWatchError(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	if mongo.IsNetworkError(err) {
+		return true
+	}
+	var srvErr mongo.ServerError
+	if errors.As(err, &srvErr) {
+		return srvErr.HasErrorLabel("ResumableChangeStreamError")
+	}
+	return false
+}
+
+type CollectionTokenStore struct {
+	client     *Client
+	collection string
+}
+
+func NewCollectionTokenStore(client *Client, collection string) *CollectionTokenStore {
+	return &CollectionTokenStore{
+		client:     client,
+		collection: collection,
+	}
+}
+
+type storedToken struct {
+	Name      string    `bson:"_id"`
+	Token     []byte    `bson:"token"`
+	UpdatedAt time.Time `bson:"updatedAt"`
+}
+
+func (s *CollectionTokenStore) LoadToken(ctx context.Context, name string) ([]byte, error) {
+	var rec storedToken
+	ok, err := s.client.FindOneByIdCtx(ctx, s.collection, name, &rec)
+	if !ok || err != nil {
+		return nil, err
+	}
+	return rec.Token, nil
+}
+
+func (s *CollectionTokenStore) SaveToken(ctx context.Context, name string, token []byte) error {
+	_, err := s.client.UpsertOneByIdCtx(ctx, s.collection, name, bson.M{
+		"token":     token,
+		"updatedAt": time.Now().UTC(),
+	})
+	return err
+}
+
+type RedisTokenStore struct {
+	client *redisutils.Client
+	prefix redisutils.Key
+}
+
+func NewRedisTokenStore(client *redisutils.Client, prefix redisutils.Key) *RedisTokenStore {
+	return &RedisTokenStore{
+		client: client,
+		prefix: prefix,
+	}
+}
+
+func (s *RedisTokenStore) key(name string) redisutils.Key {
+	return s.prefix.Append(name)
+}
+
+func (s *RedisTokenStore) LoadToken(ctx context.Context, name string) ([]byte, error) {
+	var token []byte
+	if _, err := s.client.GetCtx(ctx, s.key(name), &token); err != nil {
+		return nil, err
+	}
+	return token, nil
+}
+
+func (s *RedisTokenStore) SaveToken(ctx context.Context, name string, token []byte) error {
+	return s.client.SetCtx(ctx, s.key(name), token, 0)
+}
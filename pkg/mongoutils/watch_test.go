@@ -0,0 +1,82 @@
+package mongoutils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/sandrolain/go-utilities/pkg/redisutils"
+	"github.com/sandrolain/go-utilities/pkg/testredisutils"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func TestResumeTokenStores(t *testing.T) {
+	client := newTestClient(t)
+	redisMock := testredisutils.NewMockServer(t, "password")
+	red, err := redisutils.NewClient(redisMock.Addr(), "password", nil, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	stores := []ResumeTokenStore{
+		NewCollectionTokenStore(client, "watch_tokens"),
+		NewRedisTokenStore(red, redisutils.Key{"watch"}),
+	}
+	for _, store := range stores {
+		token, err := store.LoadToken(ctx, "users")
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Empty(t, token)
+
+		if err := store.SaveToken(ctx, "users", []byte("token")); err != nil {
+			t.Fatal(err)
+		}
+		token, err = store.LoadToken(ctx, "users")
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, []byte("token"), token)
+	}
+}
+
+func TestWatchStandalone(t *testing.T) {
+	client := newTestClient(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	err := Watch(ctx, client, "watch", WatchOptions{}, func(ev ChangeEvent[bson.M]) error {
+		return nil
+	})
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestRedisTokenStoreContext(t *testing.T) {
+	redisMock := testredisutils.NewMockServer(t, "password")
+	red, err := redisutils.NewClient(redisMock.Addr(), "password", nil, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := NewRedisTokenStore(red, redisutils.Key{"watch"})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.ErrorIs(t, store.SaveToken(ctx, "users", []byte("token")), context.Canceled)
+	_, err = store.LoadToken(ctx, "users")
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestIsResumableWatchError(t *testing.T) {
+	assert.True(t, isResumableWatchError(mongo.CommandError{Labels: []string{"NetworkError"}}))
+	assert.True(t, isResumableWatchError(mongo.CommandError{Code: 43, Labels: []string{"ResumableChangeStreamError"}}))
+	assert.False(t, isResumableWatchError(mongo.CommandError{Code: 18, Name: "AuthenticationFailed"}))
+	assert.False(t, isResumableWatchError(context.Canceled))
+	assert.False(t, isResumableWatchError(fmt.Errorf("open: %w", context.DeadlineExceeded)))
+	assert.False(t, isResumableWatchError(errors.New("invalid pipeline")))
+}
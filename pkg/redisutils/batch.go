@@ -0,0 +1,233 @@
+package redisutils
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/sandrolain/go-utilities/pkg/crudutils"
+)
+
+type KeyValue struct {
+	Key   Key
+	Value interface{}
+}
+
+// SetMany stores all the values with the same ttl in a single pipeline.
+func (c *Client) SetMany(values []KeyValue, ttl time.Duration) error {
+	return c.SetManyCtx(context.Background(), values, ttl)
+}
+
+func (c *Client) SetManyCtx(ctx context.Context, values []KeyValue, ttl time.Duration) error {
+	if len(values) == 0 {
+		return nil
+	}
+	data := make([][]byte, len(values))
+	for i, kv := range values {
+		b, err := c.Codec().Encode(kv.Value)
+		if err != nil {
+			return err
+		}
+		data[i] = b
+	}
+	ctx, cancel := withTimeout(ctx, c.timeout)
+	defer cancel()
+	_, err := c.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i, kv := range values {
+			pipe.Set(ctx, c.name(kv.Key), data[i], ttl)
+		}
+		return nil
+	})
+	return err
+}
+
+// GetMany returns the values found at keys, indexed by their key string.
+func GetMany[T interface{}](c *Client, keys ...Key) (map[string]T, error) {
+	return GetManyCtx[T](context.Background(), c, keys...)
+}
+
+func GetManyCtx[T interface{}](ctx context.Context, c *Client, keys ...Key) (map[string]T, error) {
+	res := make(map[string]T, len(keys))
+	if len(keys) == 0 {
+		return res, nil
+	}
+	ctx, cancel := withTimeout(ctx, c.timeout)
+	defer cancel()
+	values, err := c.getRaw(ctx, c.names(keys))
+	if err != nil {
+		return nil, err
+	}
+	codec := c.Codec()
+	for i, v := range values {
+		s, ok := v.(string)
+		if !ok {
+			continue
+		}
+		var value T
+		if err := codec.Decode([]byte(s), &value); err != nil {
+			return nil, err
+		}
+		res[keys[i].String()] = value
+	}
+	return res, nil
+}
+
+func (c *Client) DeleteMany(keys ...Key) (int64, error) {
+	return c.DeleteManyCtx(context.Background(), keys...)
+}
+
+func (c *Client) DeleteManyCtx(ctx context.Context, keys ...Key) (int64, error) {
+	ctx, cancel := withTimeout(ctx, c.timeout)
+	defer cancel()
+	return c.unlink(ctx, c.names(keys), false)
+}
+
+// DeleteByPrefix removes every key under prefix, one SCAN page at a time,
+// with UNLINK so that the memory is reclaimed in the background.
+func (c *Client) DeleteByPrefix(prefix Key) (int64, error) {
+	return c.DeleteByPrefixCtx(context.Background(), prefix)
+}
+
+func (c *Client) DeleteByPrefixCtx(ctx context.Context, prefix Key) (int64, error) {
+	nodes, err := c.scanNodes(ctx)
+	if err != nil {
+		return 0, err
+	}
+	pattern := c.pattern(prefix)
+	var deleted int64
+	for _, node := range nodes {
+		var cursor uint64
+		for {
+			tctx, cancel := withTimeout(ctx, c.timeout)
+			var keys []string
+			keys, cursor, err = node.Scan(tctx, cursor, pattern, DefaultScanBatchSize).Result()
+			if err == nil && len(keys) > 0 {
+				var n int64
+				n, err = c.unlink(tctx, keys, true)
+				deleted += n
+			}
+			cancel()
+			if err != nil {
+				return deleted, err
+			}
+			if cursor == 0 {
+				break
+			}
+		}
+	}
+	return deleted, nil
+}
+
+// unlink deletes keys with one command per key in a pipeline, since on a
+// Cluster they may belong to different slots.
+func (c *Client) unlink(ctx context.Context, keys []string, async bool) (int64, error) {
+	if len(keys) == 0 {
+		return 0, nil
+	}
+	cmds := make([]*redis.IntCmd, len(keys))
+	_, err := c.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i, k := range keys {
+			if async {
+				cmds[i] = pipe.Unlink(ctx, k)
+			} else {
+				cmds[i] = pipe.Del(ctx, k)
+			}
+		}
+		return nil
+	})
+	var n int64
+	for _, cmd := range cmds {
+		n += cmd.Val()
+	}
+	return n, err
+}
+
+// Tx reads the watched keys and queues the writes executed by Client.Tx.
+type Tx struct {
+	client *Client
+	tx     *redis.Tx
+	ctx    context.Context
+	writes []func(pipe redis.Pipeliner)
+}
+
+func (t *Tx) Context() context.Context {
+	return t.ctx
+}
+
+func (t *Tx) Get(key Key, value interface{}) (bool, error) {
+	return t.client.decodeResult(t.tx.Get(t.ctx, t.client.name(key)), value)
+}
+
+func (t *Tx) Set(key Key, value interface{}, ttl time.Duration) error {
+	data, err := t.client.Codec().Encode(value)
+	if err != nil {
+		return err
+	}
+	t.writes = append(t.writes, func(pipe redis.Pipeliner) {
+		pipe.Set(t.ctx, t.client.name(key), data, ttl)
+	})
+	return nil
+}
+
+func (t *Tx) Delete(key Key) {
+	t.writes = append(t.writes, func(pipe redis.Pipeliner) {
+		pipe.Del(t.ctx, t.client.name(key))
+	})
+}
+
+// Tx runs fn watching keys and then executes its writes atomically with
+// MULTI/EXEC. If a watched key changes in the meantime fn runs again, up to
+// attempts times, after which a crudutils.Conflict error is returned.
+func (c *Client) Tx(ctx context.Context, keys []Key, attempts int, fn func(tx *Tx) error) error {
+	names := c.names(keys)
+	if attempts <= 0 {
+		attempts = 1
+	}
+	for i := 0; i < attempts; i++ {
+		tctx, cancel := withTimeout(ctx, c.timeout)
+		err := c.client.Watch(tctx, func(rtx *redis.Tx) error {
+			tx := &Tx{client: c, tx: rtx, ctx: tctx}
+			if err := fn(tx); err != nil {
+				return err
+			}
+			if len(tx.writes) == 0 {
+				return nil
+			}
+			_, err := rtx.TxPipelined(tctx, func(pipe redis.Pipeliner) error {
+				for _, w := range tx.writes {
+					w(pipe)
+				}
+				return nil
+			})
+			return err
+		}, names...)
+		cancel()
+		if err != redis.TxFailedErr {
+			return err
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+	}
+	return crudutils.Conflict(strings.Join(names, ","))
+}
+
+// Update applies mutate to the value stored at key, if any, and stores the
+// result with a check-and-set transaction.
+func Update[T interface{}](ctx context.Context, c *Client, key Key, ttl time.Duration, attempts int, mutate func(value T, exists bool) (T, error)) (T, error) {
+	var res T
+	err := c.Tx(ctx, []Key{key}, attempts, func(tx *Tx) error {
+		var value T
+		ok, err := tx.Get(key, &value)
+		if err != nil {
+			return err
+		}
+		res, err = mutate(value, ok)
+		if err != nil {
+			return err
+		}
+		return tx.Set(key, res, ttl)
+	})
+	return res, err
+}
@@ -0,0 +1,90 @@
+package redisutils
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sandrolain/go-utilities/pkg/crudutils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetGetMany(t *testing.T) {
+	red := newTestClient(t)
+
+	values := []KeyValue{}
+	keys := []Key{}
+	for i := 0; i < 5; i++ {
+		key := Key{"testing", "many", fmt.Sprint(i)}
+		values = append(values, KeyValue{key, &TestStruct{"many", i}})
+		keys = append(keys, key)
+	}
+	if err := red.SetMany(values, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := GetMany[TestStruct](red, append(keys, Key{"testing", "missing"})...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Len(t, res, 5)
+	assert.Equal(t, TestStruct{"many", 2}, res["testing:many:2"])
+
+	n, err := red.DeleteMany(keys[0], keys[1], Key{"testing", "missing"})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), n)
+
+	assert.Nil(t, red.Set(Key{"testing", "kept"}, 1, 0))
+	n, err = red.DeleteByPrefix(Key{"testing", "many"})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(3), n)
+
+	ok, err := red.Get(Key{"testing", "kept"}, new(int))
+	assert.Nil(t, err)
+	assert.True(t, ok)
+}
+
+func TestTx(t *testing.T) {
+	red := newTestClient(t)
+	key := Key{"testing", "counter"}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := Update(context.Background(), red, key, 0, 100, func(value int, exists bool) (int, error) {
+				return value + 1, nil
+			})
+			assert.Nil(t, err)
+		}()
+	}
+	wg.Wait()
+
+	var res int
+	ok, err := red.Get(key, &res)
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, 10, res)
+}
+
+func TestTxConflict(t *testing.T) {
+	red := newTestClient(t)
+	key := Key{"testing", "conflict"}
+
+	err := red.Tx(context.Background(), []Key{key}, 2, func(tx *Tx) error {
+		// a concurrent write to a watched key aborts the transaction
+		if err := red.Set(key, 1, 0); err != nil {
+			return err
+		}
+		tx.Delete(key)
+		return nil
+	})
+	assert.True(t, crudutils.IsConflict(err))
+
+	ok, err := red.Get(key, new(int))
+	assert.Nil(t, err)
+	assert.True(t, ok)
+}
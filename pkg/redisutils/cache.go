@@ -0,0 +1,157 @@
+package redisutils
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/sandrolain/go-utilities/pkg/crudutils"
+	"golang.org/x/sync/singleflight"
+)
+
+// loadGroups deduplicates the concurrent loads of a client, with a group per
+// value type so that callers loading the same key as different types do not
+// share results.
+type loadGroups struct {
+	mu     sync.Mutex
+	groups map[reflect.Type]*singleflight.Group
+}
+
+func (g *loadGroups) group(t reflect.Type) *singleflight.Group {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.groups == nil {
+		g.groups = map[reflect.Type]*singleflight.Group{}
+	}
+	res, ok := g.groups[t]
+	if !ok {
+		res = &singleflight.Group{}
+		g.groups[t] = res
+	}
+	return res
+}
+
+type LoadOptions struct {
+	Ttl time.Duration
+	// Jitter randomizes the TTL by up to the given fraction, e.g. 0.1 for ±10%.
+	// It is capped at MaxJitter so that the TTL stays positive.
+	Jitter float64
+	// NotFoundTtl enables the caching of crudutils.NotFound loader errors.
+	NotFoundTtl time.Duration
+	// EarlyRefresh is the beta factor of the probabilistic early expiration:
+	// values are recomputed before they expire with a probability growing
+	// with the load time and the closeness of the expiry. Zero disables it.
+	EarlyRefresh float64
+}
+
+// cacheEntry is the envelope stored by GetOrLoad. Values written with Set
+// lack the Cached marker and are rejected instead of being decoded as zero.
+type cacheEntry[T interface{}] struct {
+	Cached   bool
+	Value    T
+	NotFound bool
+	Delta    time.Duration
+	Expiry   time.Time
+}
+
+// GetOrLoad returns the value cached at key or stores the one returned by
+// loader. Concurrent loads of the same key by the client are deduplicated.
+// Keys used by GetOrLoad must not be written with Set, as the value is
+// stored in an envelope.
+func GetOrLoad[T interface{}](c *Client, key Key, ttl time.Duration, loader func(ctx context.Context) (T, error)) (T, error) {
+	return GetOrLoadCtx(context.Background(), c, key, LoadOptions{Ttl: ttl}, loader)
+}
+
+func GetOrLoadCtx[T interface{}](ctx context.Context, c *Client, key Key, opts LoadOptions, loader func(ctx context.Context) (T, error)) (T, error) {
+	var entry cacheEntry[T]
+	gctx, cancel := withTimeout(ctx, c.timeout)
+	ok, err := c.get(gctx, key, &entry)
+	cancel()
+	if err == nil && ok && !entry.Cached {
+		err = fmt.Errorf("value at %v was not stored by GetOrLoad", key.String())
+	}
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	if ok && !shouldRefresh(entry, opts.EarlyRefresh) {
+		return entry.result(key)
+	}
+
+	group := c.loads.group(reflect.TypeOf((*T)(nil)).Elem())
+	v, err, _ := group.Do(c.name(key), func() (interface{}, error) {
+		return load(ctx, c, key, opts, loader)
+	})
+	if err != nil {
+		// a failed early refresh still serves the cached value
+		if ok {
+			return entry.result(key)
+		}
+		var zero T
+		return zero, err
+	}
+	loaded, valid := v.(cacheEntry[T])
+	if !valid {
+		var zero T
+		return zero, fmt.Errorf("concurrent load of %v returned %T", key.String(), v)
+	}
+	return loaded.result(key)
+}
+
+func load[T interface{}](ctx context.Context, c *Client, key Key, opts LoadOptions, loader func(ctx context.Context) (T, error)) (cacheEntry[T], error) {
+	start := time.Now()
+	value, err := loader(ctx)
+	entry := cacheEntry[T]{
+		Cached: true,
+		Value:  value,
+		Delta:  time.Since(start),
+	}
+	ttl := opts.Ttl
+	if err != nil {
+		if !crudutils.IsNotFound(err) || opts.NotFoundTtl <= 0 {
+			return entry, err
+		}
+		entry.NotFound = true
+		ttl = opts.NotFoundTtl
+	}
+	ttl = jitterTtl(ttl, opts.Jitter)
+	if ttl > 0 {
+		entry.Expiry = time.Now().Add(ttl)
+	}
+	sctx, cancel := withTimeout(ctx, c.timeout)
+	defer cancel()
+	return entry, c.set(sctx, key, &entry, ttl)
+}
+
+func (e cacheEntry[T]) result(key Key) (T, error) {
+	if e.NotFound {
+		var zero T
+		return zero, crudutils.NotFound(key.String())
+	}
+	return e.Value, nil
+}
+
+func shouldRefresh[T interface{}](e cacheEntry[T], beta float64) bool {
+	if beta <= 0 || e.Expiry.IsZero() {
+		return false
+	}
+	gap := time.Duration(float64(e.Delta) * beta * -math.Log(1-rand.Float64()))
+	return time.Now().Add(gap).After(e.Expiry)
+}
+
+// MaxJitter keeps jittered TTLs at least half of the requested one.
+const MaxJitter = 0.5
+
+func jitterTtl(ttl time.Duration, jitter float64) time.Duration {
+	if ttl <= 0 || jitter <= 0 {
+		return ttl
+	}
+	if jitter > MaxJitter {
+		jitter = MaxJitter
+	}
+	return ttl + time.Duration(float64(ttl)*jitter*(2*rand.Float64()-1))
+}
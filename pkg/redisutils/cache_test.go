@@ -0,0 +1,149 @@
+package redisutils
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sandrolain/go-utilities/pkg/crudutils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetOrLoad(t *testing.T) {
+	red := newTestClient(t)
+	key := Key{"testing", "cache"}
+
+	var calls int32
+	loader := func(ctx context.Context) (TestStruct, error) {
+		atomic.AddInt32(&calls, 1)
+		time.Sleep(50 * time.Millisecond)
+		return TestStruct{"loaded", 1}, nil
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			res, err := GetOrLoad(red, key, time.Minute, loader)
+			assert.Nil(t, err)
+			assert.Equal(t, TestStruct{"loaded", 1}, res)
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+
+	res, err := GetOrLoad(red, key, time.Minute, loader)
+	assert.Nil(t, err)
+	assert.Equal(t, "loaded", res.Foo)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
+
+func TestGetOrLoadNotFound(t *testing.T) {
+	red := newTestClient(t)
+	key := Key{"testing", "cacheMissing"}
+
+	calls := 0
+	loader := func(ctx context.Context) (TestStruct, error) {
+		calls++
+		return TestStruct{}, crudutils.NotFound("missing")
+	}
+	opts := LoadOptions{Ttl: time.Minute, NotFoundTtl: time.Minute}
+	for i := 0; i < 3; i++ {
+		_, err := GetOrLoadCtx(context.Background(), red, key, opts, loader)
+		assert.True(t, crudutils.IsNotFound(err))
+	}
+	assert.Equal(t, 1, calls)
+
+	failure := errors.New("failure")
+	_, err := GetOrLoad(red, Key{"testing", "cacheFailure"}, time.Minute, func(ctx context.Context) (int, error) {
+		return 0, failure
+	})
+	assert.Equal(t, failure, err)
+	ok, err := red.Get(Key{"testing", "cacheFailure"}, &TestStruct{})
+	assert.Nil(t, err)
+	assert.False(t, ok)
+}
+
+func TestGetOrLoadEarlyRefresh(t *testing.T) {
+	red := newTestClient(t)
+	key := Key{"testing", "cacheRefresh"}
+
+	calls := 0
+	loader := func(ctx context.Context) (int, error) {
+		calls++
+		time.Sleep(20 * time.Millisecond)
+		return calls, nil
+	}
+	// a huge beta makes the refresh certain
+	opts := LoadOptions{Ttl: time.Minute, EarlyRefresh: 1e6}
+	GetOrLoadCtx(context.Background(), red, key, opts, loader)
+	res, err := GetOrLoadCtx(context.Background(), red, key, opts, loader)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, res)
+}
+
+func TestGetOrLoadTypes(t *testing.T) {
+	red := newTestClient(t).WithCodec(JSONCodec)
+	key := Key{"testing", "cacheTypes"}
+
+	release := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		_, err := GetOrLoad(red, key, time.Minute, func(ctx context.Context) (int, error) {
+			<-release
+			return 1, nil
+		})
+		assert.Nil(t, err)
+	}()
+	go func() {
+		defer wg.Done()
+		_, err := GetOrLoad(red, key, time.Minute, func(ctx context.Context) (string, error) {
+			<-release
+			return "one", nil
+		})
+		assert.Nil(t, err)
+	}()
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	other := newTestClient(t)
+	var calls int32
+	res, err := GetOrLoad(other, key, time.Minute, func(ctx context.Context) (int, error) {
+		atomic.AddInt32(&calls, 1)
+		return 2, nil
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, 2, res)
+	assert.Equal(t, int32(1), calls)
+}
+
+func TestGetOrLoadPlainValue(t *testing.T) {
+	red := newTestClient(t).WithCodec(JSONCodec)
+	key := Key{"testing", "cachePlain"}
+	if err := red.Set(key, TestStruct{"plain", 1}, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	_, err := GetOrLoad(red, key, time.Minute, func(ctx context.Context) (TestStruct, error) {
+		return TestStruct{"loaded", 2}, nil
+	})
+	assert.NotNil(t, err)
+}
+
+func TestJitterTtl(t *testing.T) {
+	assert.Equal(t, time.Minute, jitterTtl(time.Minute, 0))
+	for i := 0; i < 100; i++ {
+		ttl := jitterTtl(time.Minute, 0.1)
+		assert.True(t, ttl >= 54*time.Second && ttl <= 66*time.Second)
+	}
+	for i := 0; i < 100; i++ {
+		ttl := jitterTtl(time.Minute, 2)
+		assert.True(t, ttl >= 30*time.Second && ttl <= 90*time.Second)
+	}
+}
@@ -0,0 +1,185 @@
+package redisutils
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/golang/snappy"
+	"github.com/sandrolain/go-utilities/pkg/cryptoutils"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+type Codec interface {
+	Encode(value interface{}) ([]byte, error)
+	Decode(data []byte, value interface{}) error
+}
+
+var (
+	GobCodec     Codec = gobCodec{}
+	JSONCodec    Codec = jsonCodec{}
+	MsgpackCodec Codec = msgpackCodec{}
+	// RawCodec stores []byte and string values as they are.
+	RawCodec Codec = rawCodec{}
+)
+
+var DefaultCodec = GobCodec
+
+type gobCodec struct{}
+
+func (gobCodec) Encode(value interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(value); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (gobCodec) Decode(data []byte, value interface{}) error {
+	return gob.NewDecoder(bytes.NewReader(data)).Decode(value)
+}
+
+type jsonCodec struct{}
+
+func (jsonCodec) Encode(value interface{}) ([]byte, error) {
+	return json.Marshal(value)
+}
+
+func (jsonCodec) Decode(data []byte, value interface{}) error {
+	return json.Unmarshal(data, value)
+}
+
+type msgpackCodec struct{}
+
+func (msgpackCodec) Encode(value interface{}) ([]byte, error) {
+	return msgpack.Marshal(value)
+}
+
+func (msgpackCodec) Decode(data []byte, value interface{}) error {
+	return msgpack.Unmarshal(data, value)
+}
+
+type rawCodec struct{}
+
+func (rawCodec) Encode(value interface{}) ([]byte, error) {
+	switch v := value.(type) {
+	case []byte:
+		return v, nil
+	case *[]byte:
+		return *v, nil
+	case string:
+		return []byte(v), nil
+	case *string:
+		return []byte(*v), nil
+	}
+	return nil, fmt.Errorf("raw codec cannot encode %T", value)
+}
+
+func (rawCodec) Decode(data []byte, value interface{}) error {
+	switch v := value.(type) {
+	case *[]byte:
+		*v = append([]byte{}, data...)
+		return nil
+	case *string:
+		*v = string(data)
+		return nil
+	}
+	return fmt.Errorf("raw codec cannot decode into %T", value)
+}
+
+type gzipCodec struct {
+	codec Codec
+	level int
+}
+
+// GzipCodec compresses the output of codec with the given gzip level.
+func GzipCodec(codec Codec, level int) Codec {
+	return gzipCodec{codec, level}
+}
+
+func (c gzipCodec) Encode(value interface{}) ([]byte, error) {
+	data, err := c.codec.Encode(value)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	w, err := gzip.NewWriterLevel(&buf, c.level)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (c gzipCodec) Decode(data []byte, value interface{}) error {
+	r, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	plain, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	return c.codec.Decode(plain, value)
+}
+
+type snappyCodec struct {
+	codec Codec
+}
+
+func SnappyCodec(codec Codec) Codec {
+	return snappyCodec{codec}
+}
+
+func (c snappyCodec) Encode(value interface{}) ([]byte, error) {
+	data, err := c.codec.Encode(value)
+	if err != nil {
+		return nil, err
+	}
+	return snappy.Encode(nil, data), nil
+}
+
+func (c snappyCodec) Decode(data []byte, value interface{}) error {
+	plain, err := snappy.Decode(nil, data)
+	if err != nil {
+		return err
+	}
+	return c.codec.Decode(plain, value)
+}
+
+type encryptedCodec struct {
+	codec      Codec
+	passPhrase [32]byte
+}
+
+// EncryptedCodec encrypts the output of codec with cryptoutils.Encrypt.
+// Compression wrappers should be placed inside it, as encrypted data does not
+// compress.
+func EncryptedCodec(codec Codec, passPhrase [32]byte) Codec {
+	return encryptedCodec{codec, passPhrase}
+}
+
+func (c encryptedCodec) Encode(value interface{}) ([]byte, error) {
+	data, err := c.codec.Encode(value)
+	if err != nil {
+		return nil, err
+	}
+	return cryptoutils.Encrypt(data, c.passPhrase)
+}
+
+func (c encryptedCodec) Decode(data []byte, value interface{}) error {
+	plain, err := cryptoutils.Decrypt(data, c.passPhrase)
+	if err != nil {
+		return err
+	}
+	return c.codec.Decode(plain, value)
+}
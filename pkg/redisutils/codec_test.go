@@ -0,0 +1,101 @@
+package redisutils
+
+import (
+	"compress/gzip"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCodecs(t *testing.T) {
+	var passPhrase [32]byte
+	copy(passPhrase[:], "0123456789abcdef0123456789abcdef")
+
+	codecs := map[string]Codec{
+		"gob":       GobCodec,
+		"json":      JSONCodec,
+		"msgpack":   MsgpackCodec,
+		"gzip":      GzipCodec(JSONCodec, gzip.BestSpeed),
+		"snappy":    SnappyCodec(MsgpackCodec),
+		"encrypted": EncryptedCodec(SnappyCodec(GobCodec), passPhrase),
+	}
+	for name, codec := range codecs {
+		t.Run(name, func(t *testing.T) {
+			val := TestStruct{"hello", 123}
+			data, err := codec.Encode(&val)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var res TestStruct
+			if err := codec.Decode(data, &res); err != nil {
+				t.Fatal(err)
+			}
+			assert.Equal(t, val, res)
+		})
+	}
+}
+
+func TestRawCodec(t *testing.T) {
+	data, err := RawCodec.Encode("hello")
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("hello"), data)
+
+	var s string
+	assert.Nil(t, RawCodec.Decode(data, &s))
+	assert.Equal(t, "hello", s)
+
+	_, err = RawCodec.Encode(123)
+	assert.NotNil(t, err)
+	assert.NotNil(t, RawCodec.Decode(data, &TestStruct{}))
+}
+
+func TestClientCodec(t *testing.T) {
+	red := newTestClient(t)
+	key := Key{"testing", "codec"}
+
+	jsonRed := red.WithCodec(JSONCodec)
+	if err := jsonRed.Set(key, &TestStruct{"hello", 123}, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+
+	var raw string
+	ok, err := red.WithCodec(RawCodec).Get(key, &raw)
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, `{"Foo":"hello","Bar":123}`, raw)
+
+	var res TestStruct
+	ok, err = jsonRed.Get(key, &res)
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, TestStruct{"hello", 123}, res)
+
+	assert.Equal(t, GobCodec, red.Codec())
+	red.SetCodec(JSONCodec)
+	assert.Equal(t, JSONCodec, red.Codec())
+}
+
+func TestEncryptedCodec(t *testing.T) {
+	var passPhrase [32]byte
+	copy(passPhrase[:], "0123456789abcdef0123456789abcdef")
+	red := newTestClient(t).WithCodec(EncryptedCodec(JSONCodec, passPhrase))
+
+	first, err := red.Codec().Encode("hello")
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := red.Codec().Encode("hello")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.NotEqual(t, first, second)
+
+	key := Key{"testing", "encrypted"}
+	if err := red.WithCodec(RawCodec).Set(key, string(first[:10]), time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	var res string
+	_, err = red.Get(key, &res)
+	assert.NotNil(t, err)
+}
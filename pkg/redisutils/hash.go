@@ -0,0 +1,196 @@
+package redisutils
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/go-redis/redis/v8"
+)
+
+func (c *Client) HSet(key Key, field string, value interface{}) error {
+	data, err := c.Codec().Encode(value)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := createContext(c.timeout)
+	defer cancel()
+	return c.client.HSet(ctx, c.name(key), field, data).Err()
+}
+
+func (c *Client) HGet(key Key, field string, value interface{}) (bool, error) {
+	ctx, cancel := createContext(c.timeout)
+	defer cancel()
+	data, err := c.client.HGet(ctx, c.name(key), field).Bytes()
+	if err == redis.Nil {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, c.Codec().Decode(data, value)
+}
+
+func (c *Client) HDel(key Key, fields ...string) (int64, error) {
+	ctx, cancel := createContext(c.timeout)
+	defer cancel()
+	return c.client.HDel(ctx, c.name(key), fields...).Result()
+}
+
+func (c *Client) HExists(key Key, field string) (bool, error) {
+	ctx, cancel := createContext(c.timeout)
+	defer cancel()
+	return c.client.HExists(ctx, c.name(key), field).Result()
+}
+
+func (c *Client) HLen(key Key) (int64, error) {
+	ctx, cancel := createContext(c.timeout)
+	defer cancel()
+	return c.client.HLen(ctx, c.name(key)).Result()
+}
+
+func (c *Client) HIncrBy(key Key, field string, incr int64) (int64, error) {
+	ctx, cancel := createContext(c.timeout)
+	defer cancel()
+	return c.client.HIncrBy(ctx, c.name(key), field, incr).Result()
+}
+
+// HSetStruct stores the exported fields of the struct pointed to by value as
+// hash fields, named after their `redis` tag if present. Strings, numbers and
+// booleans are stored as plain text, so they can be used with HIncrBy, other
+// fields are encoded with the client codec. Fields tagged "-" are skipped.
+func (c *Client) HSetStruct(key Key, value interface{}) error {
+	v := reflect.Indirect(reflect.ValueOf(value))
+	if v.Kind() != reflect.Struct {
+		return fmt.Errorf("value must be a struct, got %T", value)
+	}
+	values := map[string]interface{}{}
+	for i, f := range hashFields(v.Type()) {
+		if f == "" {
+			continue
+		}
+		field := v.Field(i)
+		if s, ok := formatScalar(field); ok {
+			values[f] = s
+			continue
+		}
+		data, err := c.Codec().Encode(field.Interface())
+		if err != nil {
+			return fmt.Errorf("cannot encode field %v: %w", f, err)
+		}
+		values[f] = data
+	}
+	if len(values) == 0 {
+		return nil
+	}
+	ctx, cancel := createContext(c.timeout)
+	defer cancel()
+	return c.client.HSet(ctx, c.name(key), values).Err()
+}
+
+// HGetStruct fills the struct pointed to by value from a hash written by
+// HSetStruct. Hash fields without a matching struct field are ignored.
+func (c *Client) HGetStruct(key Key, value interface{}) (bool, error) {
+	v := reflect.ValueOf(value)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+		return false, fmt.Errorf("value must be a pointer to a struct, got %T", value)
+	}
+	v = v.Elem()
+	ctx, cancel := createContext(c.timeout)
+	defer cancel()
+	values, err := c.client.HGetAll(ctx, c.name(key)).Result()
+	if err != nil {
+		return false, err
+	}
+	if len(values) == 0 {
+		return false, nil
+	}
+	for i, f := range hashFields(v.Type()) {
+		s, ok := values[f]
+		if f == "" || !ok {
+			continue
+		}
+		field := v.Field(i)
+		ok, err := parseScalar(s, field)
+		if err != nil {
+			return true, fmt.Errorf("cannot parse field %v: %w", f, err)
+		}
+		if ok {
+			continue
+		}
+		if err := c.Codec().Decode([]byte(s), field.Addr().Interface()); err != nil {
+			return true, fmt.Errorf("cannot decode field %v: %w", f, err)
+		}
+	}
+	return true, nil
+}
+
+func hashFields(t reflect.Type) []string {
+	res := make([]string, t.NumField())
+	for i := range res {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name := strings.Split(f.Tag.Get("redis"), ",")[0]
+		switch name {
+		case "-":
+		case "":
+			res[i] = f.Name
+		default:
+			res[i] = name
+		}
+	}
+	return res
+}
+
+func formatScalar(v reflect.Value) (string, bool) {
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), true
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10), true
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'g', -1, 64), true
+	}
+	return "", false
+}
+
+func parseScalar(s string, v reflect.Value) (bool, error) {
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return true, err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, v.Type().Bits())
+		if err != nil {
+			return true, err
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, v.Type().Bits())
+		if err != nil {
+			return true, err
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(s, v.Type().Bits())
+		if err != nil {
+			return true, err
+		}
+		v.SetFloat(n)
+	default:
+		return false, nil
+	}
+	return true, nil
+}
@@ -0,0 +1,81 @@
+package redisutils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type TestHash struct {
+	Name    string `redis:"name"`
+	Visits  int64  `redis:"visits"`
+	Score   float64
+	Active  bool
+	Tags    []string
+	Ignored string `redis:"-"`
+	private string
+}
+
+func TestHashStruct(t *testing.T) {
+	red := newTestClient(t)
+	key := Key{"testing", "hash"}
+
+	val := TestHash{
+		Name:    "hello",
+		Visits:  3,
+		Score:   1.5,
+		Active:  true,
+		Tags:    []string{"a", "b"},
+		Ignored: "ignored",
+		private: "private",
+	}
+	if err := red.HSetStruct(key, &val); err != nil {
+		t.Fatal(err)
+	}
+
+	visits, err := red.HIncrBy(key, "visits", 2)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(5), visits)
+
+	exists, err := red.HExists(key, "Ignored")
+	assert.Nil(t, err)
+	assert.False(t, exists)
+
+	var res TestHash
+	ok, err := red.HGetStruct(key, &res)
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, TestHash{Name: "hello", Visits: 5, Score: 1.5, Active: true, Tags: []string{"a", "b"}}, res)
+
+	ok, err = red.HGetStruct(Key{"testing", "missingHash"}, &res)
+	assert.Nil(t, err)
+	assert.False(t, ok)
+
+	assert.NotNil(t, red.HSetStruct(key, "not a struct"))
+}
+
+func TestHashFields(t *testing.T) {
+	red := newTestClient(t)
+	key := Key{"testing", "hashFields"}
+
+	if err := red.HSet(key, "foo", &TestStruct{"hello", 1}); err != nil {
+		t.Fatal(err)
+	}
+	var res TestStruct
+	ok, err := red.HGet(key, "foo", &res)
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, TestStruct{"hello", 1}, res)
+
+	ok, err = red.HGet(key, "bar", &res)
+	assert.Nil(t, err)
+	assert.False(t, ok)
+
+	n, err := red.HLen(key)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), n)
+
+	n, err = red.HDel(key, "foo", "bar")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), n)
+}
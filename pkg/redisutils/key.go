@@ -0,0 +1,180 @@
+package redisutils
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+const (
+	KeySeparator = ':'
+	keyEscape    = '\\'
+)
+
+// NoExpiry is the TTL reported for keys without an expiration.
+const NoExpiry time.Duration = -1
+
+var keyReplacer = strings.NewReplacer(`\`, `\\`, `:`, `\:`)
+
+// Key is a Redis key made of segments joined by ":". Separators and
+// backslashes inside a segment are escaped with a backslash.
+type Key []string
+
+func NewKey(segments ...string) Key {
+	return append(Key{}, segments...)
+}
+
+// Append returns a new key with segments added, never sharing the backing
+// array of k.
+func (k Key) Append(segments ...string) Key {
+	res := make(Key, 0, len(k)+len(segments))
+	res = append(res, k...)
+	return append(res, segments...)
+}
+
+func (k Key) String() string {
+	escaped := make([]string, len(k))
+	for i, s := range k {
+		escaped[i] = keyReplacer.Replace(s)
+	}
+	return strings.Join(escaped, string(KeySeparator))
+}
+
+// ParseKey splits a key string produced by Key.String into its segments.
+func ParseKey(s string) (Key, error) {
+	if s == "" {
+		return Key{}, nil
+	}
+	res := Key{}
+	var segment strings.Builder
+	escaped := false
+	for _, r := range s {
+		switch {
+		case escaped:
+			if r != KeySeparator && r != keyEscape {
+				return nil, fmt.Errorf("invalid escape sequence in key \"%s\"", s)
+			}
+			segment.WriteRune(r)
+			escaped = false
+		case r == keyEscape:
+			escaped = true
+		case r == KeySeparator:
+			res = append(res, segment.String())
+			segment.Reset()
+		default:
+			segment.WriteRune(r)
+		}
+	}
+	if escaped {
+		return nil, fmt.Errorf("unterminated escape sequence in key \"%s\"", s)
+	}
+	return append(res, segment.String()), nil
+}
+
+func (c *Client) Namespace() Key {
+	return c.namespace
+}
+
+// name returns the Redis key of key within the client namespace.
+func (c *Client) name(key Key) string {
+	if len(c.namespace) == 0 {
+		return key.String()
+	}
+	return c.namespace.Append(key...).String()
+}
+
+func (c *Client) names(keys []Key) []string {
+	res := make([]string, len(keys))
+	for i, k := range keys {
+		res[i] = c.name(k)
+	}
+	return res
+}
+
+func (c *Client) trimNamespace(name string) string {
+	if len(c.namespace) == 0 {
+		return name
+	}
+	return strings.TrimPrefix(name, c.namespace.String()+string(KeySeparator))
+}
+
+// TTL returns the remaining time to live of key, NoExpiry for persistent
+// keys, and false if the key does not exist.
+func (c *Client) TTL(key Key) (time.Duration, bool, error) {
+	ctx, cancel := createContext(c.timeout)
+	defer cancel()
+	ttl, err := c.client.PTTL(ctx, c.name(key)).Result()
+	if err != nil {
+		return 0, false, err
+	}
+	// go-redis reports the -1 and -2 replies as nanoseconds
+	switch ttl {
+	case -2:
+		return 0, false, nil
+	case -1:
+		return NoExpiry, true, nil
+	}
+	return ttl, true, nil
+}
+
+// Expire sets the time to live of key, returning false if it does not exist.
+func (c *Client) Expire(key Key, ttl time.Duration) (bool, error) {
+	ctx, cancel := createContext(c.timeout)
+	defer cancel()
+	return c.client.PExpire(ctx, c.name(key), ttl).Result()
+}
+
+// Persist removes the expiration of key, returning false if it does not
+// exist or has none.
+func (c *Client) Persist(key Key) (bool, error) {
+	ctx, cancel := createContext(c.timeout)
+	defer cancel()
+	return c.client.Persist(ctx, c.name(key)).Result()
+}
+
+// Exists returns how many of keys exist.
+func (c *Client) Exists(keys ...Key) (int64, error) {
+	return c.countKeys(keys, func(ctx context.Context, pipe redis.Pipeliner, name string) *redis.IntCmd {
+		return pipe.Exists(ctx, name)
+	})
+}
+
+// Touch updates the last access time of keys, returning how many exist.
+func (c *Client) Touch(keys ...Key) (int64, error) {
+	return c.countKeys(keys, func(ctx context.Context, pipe redis.Pipeliner, name string) *redis.IntCmd {
+		return pipe.Touch(ctx, name)
+	})
+}
+
+// countKeys runs cmd for every key in a pipeline, so that keys in different
+// Cluster slots are supported, and sums the results.
+func (c *Client) countKeys(keys []Key, cmd func(ctx context.Context, pipe redis.Pipeliner, name string) *redis.IntCmd) (int64, error) {
+	if len(keys) == 0 {
+		return 0, nil
+	}
+	ctx, cancel := createContext(c.timeout)
+	defer cancel()
+	cmds := make([]*redis.IntCmd, len(keys))
+	_, err := c.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i, k := range keys {
+			cmds[i] = cmd(ctx, pipe, c.name(k))
+		}
+		return nil
+	})
+	var n int64
+	for _, cmd := range cmds {
+		n += cmd.Val()
+	}
+	return n, err
+}
+
+// Rename moves the value of from to to, replacing any existing value. On a
+// Cluster both keys must belong to the same slot.
+func (c *Client) Rename(from Key, to Key) error {
+	ctx, cancel := createContext(c.timeout)
+	defer cancel()
+	return c.client.Rename(ctx, c.name(from), c.name(to)).Err()
+}
@@ -0,0 +1,94 @@
+package redisutils
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeyString(t *testing.T) {
+	assert.Equal(t, "a:b:c", NewKey("a", "b", "c").String())
+	assert.Equal(t, `user:a\:b:c\\d`, Key{"user", "a:b", `c\d`}.String())
+
+	for _, k := range []Key{{"a"}, {"a", "b"}, {"user", "a:b", `c\d`, ""}, {`\:`}} {
+		parsed, err := ParseKey(k.String())
+		assert.Nil(t, err)
+		assert.Equal(t, k, parsed)
+	}
+
+	_, err := ParseKey(`a\b`)
+	assert.NotNil(t, err)
+	_, err = ParseKey(`a\`)
+	assert.NotNil(t, err)
+}
+
+func TestKeyAppend(t *testing.T) {
+	base := make(Key, 1, 10)
+	base[0] = "base"
+	a := base.Append("a")
+	b := base.Append("b")
+	assert.Equal(t, Key{"base", "a"}, a)
+	assert.Equal(t, Key{"base", "b"}, b)
+}
+
+func TestNamespace(t *testing.T) {
+	s := miniredis.RunT(t)
+	red, err := NewClientWithOptions(s.Addr(), WithNamespace("app", "v1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer red.Close()
+	assert.Equal(t, Key{"app", "v1"}, red.Namespace())
+
+	assert.Nil(t, red.Set(Key{"user", "1"}, 1, 0))
+	assert.True(t, s.Exists("app:v1:user:1"))
+
+	all, err := ScanAll[int](red, Key{"user"})
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]int{"user:1": 1}, all)
+}
+
+func TestExpiry(t *testing.T) {
+	red := newTestClient(t)
+	key := Key{"testing", "ttl"}
+
+	_, ok, err := red.TTL(key)
+	assert.Nil(t, err)
+	assert.False(t, ok)
+
+	assert.Nil(t, red.SetNoTtl(key, 1))
+	ttl, ok, err := red.TTL(key)
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, NoExpiry, ttl)
+
+	ok, err = red.Expire(key, time.Minute)
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	ttl, _, err = red.TTL(key)
+	assert.Nil(t, err)
+	assert.True(t, ttl > 59*time.Second && ttl <= time.Minute)
+
+	ok, err = red.Persist(key)
+	assert.Nil(t, err)
+	assert.True(t, ok)
+
+	n, err := red.Exists(key, Key{"testing", "missing"})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), n)
+
+	n, err = red.Touch(key)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), n)
+
+	renamed := Key{"testing", "renamed"}
+	assert.Nil(t, red.Rename(key, renamed))
+	var v int
+	ok, err = red.Get(renamed, &v)
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, 1, v)
+	assert.NotNil(t, red.Rename(key, renamed))
+}
@@ -0,0 +1,116 @@
+package redisutils
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+func encodeAll(codec Codec, values []interface{}) ([]interface{}, error) {
+	res := make([]interface{}, len(values))
+	for i, v := range values {
+		data, err := codec.Encode(v)
+		if err != nil {
+			return nil, err
+		}
+		res[i] = data
+	}
+	return res, nil
+}
+
+func decodeAll[T interface{}](codec Codec, values []string) ([]T, error) {
+	res := make([]T, len(values))
+	for i, v := range values {
+		if err := codec.Decode([]byte(v), &res[i]); err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
+}
+
+func (c *Client) LPush(key Key, values ...interface{}) (int64, error) {
+	data, err := encodeAll(c.Codec(), values)
+	if err != nil {
+		return 0, err
+	}
+	ctx, cancel := createContext(c.timeout)
+	defer cancel()
+	return c.client.LPush(ctx, c.name(key), data...).Result()
+}
+
+func (c *Client) RPush(key Key, values ...interface{}) (int64, error) {
+	data, err := encodeAll(c.Codec(), values)
+	if err != nil {
+		return 0, err
+	}
+	ctx, cancel := createContext(c.timeout)
+	defer cancel()
+	return c.client.RPush(ctx, c.name(key), data...).Result()
+}
+
+func (c *Client) LPop(key Key, value interface{}) (bool, error) {
+	ctx, cancel := createContext(c.timeout)
+	defer cancel()
+	return c.decodeResult(c.client.LPop(ctx, c.name(key)), value)
+}
+
+func (c *Client) RPop(key Key, value interface{}) (bool, error) {
+	ctx, cancel := createContext(c.timeout)
+	defer cancel()
+	return c.decodeResult(c.client.RPop(ctx, c.name(key)), value)
+}
+
+func (c *Client) decodeResult(cmd *redis.StringCmd, value interface{}) (bool, error) {
+	data, err := cmd.Bytes()
+	if err == redis.Nil {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, c.Codec().Decode(data, value)
+}
+
+// BLPop waits up to wait for an element of the first non empty list of keys,
+// returning the key it was popped from. A zero wait blocks until ctx is done.
+func (c *Client) BLPop(ctx context.Context, wait time.Duration, value interface{}, keys ...Key) (string, bool, error) {
+	return c.blockingPop(ctx, c.client.BLPop, wait, value, keys)
+}
+
+func (c *Client) BRPop(ctx context.Context, wait time.Duration, value interface{}, keys ...Key) (string, bool, error) {
+	return c.blockingPop(ctx, c.client.BRPop, wait, value, keys)
+}
+
+func (c *Client) blockingPop(ctx context.Context, pop func(ctx context.Context, timeout time.Duration, keys ...string) *redis.StringSliceCmd, wait time.Duration, value interface{}, keys []Key) (string, bool, error) {
+	res, err := pop(ctx, wait, c.names(keys)...).Result()
+	if err == redis.Nil {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+	return c.trimNamespace(res[0]), true, c.Codec().Decode([]byte(res[1]), value)
+}
+
+func LRange[T interface{}](c *Client, key Key, start int64, stop int64) ([]T, error) {
+	ctx, cancel := createContext(c.timeout)
+	defer cancel()
+	values, err := c.client.LRange(ctx, c.name(key), start, stop).Result()
+	if err != nil {
+		return nil, err
+	}
+	return decodeAll[T](c.Codec(), values)
+}
+
+func (c *Client) LTrim(key Key, start int64, stop int64) error {
+	ctx, cancel := createContext(c.timeout)
+	defer cancel()
+	return c.client.LTrim(ctx, c.name(key), start, stop).Err()
+}
+
+func (c *Client) LLen(key Key) (int64, error) {
+	ctx, cancel := createContext(c.timeout)
+	defer cancel()
+	return c.client.LLen(ctx, c.name(key)).Result()
+}
@@ -0,0 +1,65 @@
+package redisutils
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestList(t *testing.T) {
+	red := newTestClient(t)
+	key := Key{"testing", "list"}
+
+	n, err := red.RPush(key, 1, 2, 3)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(3), n)
+	_, err = red.LPush(key, 0)
+	assert.Nil(t, err)
+
+	values, err := LRange[int](red, key, 0, -1)
+	assert.Nil(t, err)
+	assert.Equal(t, []int{0, 1, 2, 3}, values)
+
+	var v int
+	ok, err := red.RPop(key, &v)
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, 3, v)
+
+	ok, err = red.LPop(key, &v)
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, 0, v)
+
+	assert.Nil(t, red.LTrim(key, 0, 0))
+	n, err = red.LLen(key)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), n)
+
+	ok, err = red.LPop(Key{"testing", "emptyList"}, &v)
+	assert.Nil(t, err)
+	assert.False(t, ok)
+}
+
+func TestBlockingPop(t *testing.T) {
+	red := newTestClient(t)
+	key := Key{"testing", "queue"}
+
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		red.RPush(key, &TestStruct{"job", 1})
+	}()
+
+	var res TestStruct
+	from, ok, err := red.BLPop(context.Background(), time.Second, &res, Key{"testing", "other"}, key)
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "testing:queue", from)
+	assert.Equal(t, TestStruct{"job", 1}, res)
+
+	_, ok, err = red.BRPop(context.Background(), 50*time.Millisecond, &res, key)
+	assert.Nil(t, err)
+	assert.False(t, ok)
+}
@@ -0,0 +1,235 @@
+package redisutils
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/sandrolain/go-utilities/pkg/cryptoutils"
+)
+
+const (
+	DefaultLockTtl           = 30 * time.Second
+	DefaultLockRetryDelay    = 50 * time.Millisecond
+	DefaultLockMaxRetryDelay = 2 * time.Second
+	lockTokenLength          = 16
+)
+
+var (
+	ErrLockNotAcquired = errors.New("lock not acquired")
+	ErrLockNotHeld     = errors.New("lock not held")
+)
+
+var releaseScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+var extendScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0
+`)
+
+type LockOptions struct {
+	Ttl           time.Duration
+	RetryDelay    time.Duration
+	MaxRetryDelay time.Duration
+	AutoExtend    bool
+}
+
+func (o *LockOptions) defaults() {
+	if o.Ttl <= 0 {
+		o.Ttl = DefaultLockTtl
+	}
+	if o.RetryDelay <= 0 {
+		o.RetryDelay = DefaultLockRetryDelay
+	}
+	if o.MaxRetryDelay < o.RetryDelay {
+		o.MaxRetryDelay = DefaultLockMaxRetryDelay
+	}
+}
+
+type Lock struct {
+	client   *Client
+	key      Key
+	token    string
+	ttl      time.Duration
+	mu       sync.Mutex
+	released bool
+	stop     chan struct{}
+	lost     chan struct{}
+}
+
+func (c *Client) TryLock(key Key, ttl time.Duration) (*Lock, error) {
+	return c.TryLockCtx(context.Background(), key, LockOptions{Ttl: ttl})
+}
+
+func (c *Client) TryLockCtx(ctx context.Context, key Key, opts LockOptions) (*Lock, error) {
+	opts.defaults()
+	token, err := cryptoutils.RandomBytesBase64(lockTokenLength)
+	if err != nil {
+		return nil, err
+	}
+	tctx, cancel := withTimeout(ctx, c.timeout)
+	defer cancel()
+	ok, err := c.client.SetNX(tctx, c.name(key), token, opts.Ttl).Result()
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrLockNotAcquired
+	}
+	l := &Lock{
+		client: c,
+		key:    key,
+		token:  token,
+		ttl:    opts.Ttl,
+		stop:   make(chan struct{}),
+		lost:   make(chan struct{}),
+	}
+	if opts.AutoExtend {
+		go l.autoExtend()
+	}
+	return l, nil
+}
+
+// Lock retries TryLock with exponential backoff and jitter until the lock is
+// acquired or ctx is done.
+func (c *Client) Lock(ctx context.Context, key Key, opts LockOptions) (*Lock, error) {
+	opts.defaults()
+	delay := opts.RetryDelay
+	for {
+		l, err := c.TryLockCtx(ctx, key, opts)
+		if err != ErrLockNotAcquired {
+			return l, err
+		}
+		wait := delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(wait):
+		}
+		delay *= 2
+		if delay > opts.MaxRetryDelay {
+			delay = opts.MaxRetryDelay
+		}
+	}
+}
+
+func (l *Lock) Key() Key {
+	return l.key
+}
+
+func (l *Lock) Token() string {
+	return l.token
+}
+
+// Lost is closed when the lock is released or an automatic extension fails.
+func (l *Lock) Lost() <-chan struct{} {
+	return l.lost
+}
+
+func (l *Lock) Extend(ttl time.Duration) error {
+	ctx, cancel := createContext(l.client.timeout)
+	defer cancel()
+	res, err := extendScript.Run(ctx, l.client.client, []string{l.client.name(l.key)}, l.token, ttl.Milliseconds()).Int64()
+	if err != nil {
+		return err
+	}
+	if res == 0 {
+		return ErrLockNotHeld
+	}
+	return nil
+}
+
+func (l *Lock) Unlock() error {
+	l.markReleased()
+	ctx, cancel := createContext(l.client.timeout)
+	defer cancel()
+	res, err := releaseScript.Run(ctx, l.client.client, []string{l.client.name(l.key)}, l.token).Int64()
+	if err != nil {
+		return err
+	}
+	if res == 0 {
+		return ErrLockNotHeld
+	}
+	return nil
+}
+
+func (l *Lock) markReleased() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if !l.released {
+		l.released = true
+		close(l.stop)
+		close(l.lost)
+	}
+}
+
+func (l *Lock) autoExtend() {
+	ticker := time.NewTicker(l.ttl / 3)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-l.stop:
+			return
+		case <-ticker.C:
+			if err := l.Extend(l.ttl); err == ErrLockNotHeld {
+				l.markReleased()
+				return
+			}
+		}
+	}
+}
+
+type ElectionOptions struct {
+	LockOptions
+	OnElected func(ctx context.Context)
+	OnRevoked func()
+}
+
+// RunElection campaigns for the leadership on key until ctx is done. While
+// leader OnElected runs with a context cancelled on leadership loss, after
+// which OnRevoked is called and the campaign starts again.
+func (c *Client) RunElection(ctx context.Context, key Key, opts ElectionOptions) error {
+	opts.AutoExtend = true
+	for {
+		l, err := c.Lock(ctx, key, opts.LockOptions)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+
+		leaderCtx, cancel := context.WithCancel(ctx)
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			if opts.OnElected != nil {
+				opts.OnElected(leaderCtx)
+			}
+		}()
+
+		select {
+		case <-ctx.Done():
+		case <-l.Lost():
+		}
+		cancel()
+		<-done
+		if opts.OnRevoked != nil {
+			opts.OnRevoked()
+		}
+		l.Unlock()
+		if ctx.Err() != nil {
+			return nil
+		}
+	}
+}
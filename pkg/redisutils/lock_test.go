@@ -0,0 +1,138 @@
+package redisutils
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/sandrolain/go-utilities/pkg/testredisutils"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestClient(t *testing.T) *Client {
+	redisMock := testredisutils.NewMockServer(t, TestPassword)
+	red, err := NewClientWithOptions(redisMock.Addr(), WithCredentials("", TestPassword), WithTimeout(time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return red
+}
+
+func TestTryLock(t *testing.T) {
+	red := newTestClient(t)
+	key := Key{"testing", "lock"}
+
+	l, err := red.TryLock(key, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.NotEmpty(t, l.Token())
+
+	_, err = red.TryLock(key, time.Second)
+	assert.Equal(t, ErrLockNotAcquired, err)
+
+	assert.Nil(t, l.Extend(2*time.Second))
+	assert.Nil(t, l.Unlock())
+	assert.Equal(t, ErrLockNotHeld, l.Unlock())
+
+	select {
+	case <-l.Lost():
+	default:
+		t.Fatal("lost channel should be closed")
+	}
+
+	l2, err := red.TryLock(key, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Nil(t, l2.Unlock())
+}
+
+func TestLockWaits(t *testing.T) {
+	red := newTestClient(t)
+	key := Key{"testing", "lockWait"}
+
+	l, err := red.TryLock(key, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		l.Unlock()
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	l2, err := red.Lock(ctx, key, LockOptions{Ttl: time.Minute, RetryDelay: 10 * time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Nil(t, l2.Unlock())
+
+	l3, err := red.TryLock(key, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l3.Unlock()
+	ctx2, cancel2 := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel2()
+	_, err = red.Lock(ctx2, key, LockOptions{RetryDelay: 10 * time.Millisecond})
+	assert.Equal(t, context.DeadlineExceeded, err)
+}
+
+func TestLockAutoExtendLost(t *testing.T) {
+	red := newTestClient(t)
+	key := Key{"testing", "lockLost"}
+
+	l, err := red.TryLockCtx(context.Background(), key, LockOptions{Ttl: 150 * time.Millisecond, AutoExtend: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	red.Delete(key)
+
+	select {
+	case <-l.Lost():
+	case <-time.After(time.Second):
+		t.Fatal("lock should be lost")
+	}
+}
+
+func TestRunElection(t *testing.T) {
+	red := newTestClient(t)
+	key := Key{"testing", "leader"}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	elected := make(chan struct{})
+	revoked := make(chan struct{})
+	done := make(chan error)
+	go func() {
+		done <- red.RunElection(ctx, key, ElectionOptions{
+			LockOptions: LockOptions{Ttl: time.Second, RetryDelay: 10 * time.Millisecond},
+			OnElected: func(ctx context.Context) {
+				close(elected)
+				<-ctx.Done()
+			},
+			OnRevoked: func() {
+				close(revoked)
+			},
+		})
+	}()
+
+	select {
+	case <-elected:
+	case <-time.After(time.Second):
+		t.Fatal("should be elected")
+	}
+	_, err := red.TryLock(key, time.Second)
+	assert.Equal(t, ErrLockNotAcquired, err)
+
+	cancel()
+	<-revoked
+	assert.Nil(t, <-done)
+
+	l, err := red.TryLock(key, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.Unlock()
+}
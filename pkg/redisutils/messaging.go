@@ -0,0 +1,365 @@
+package redisutils
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+const (
+	DefaultRetryDelay          = time.Second
+	DefaultStreamBlock         = 5 * time.Second
+	DefaultStreamBatchSize     = 10
+	DefaultStreamMinIdle       = 30 * time.Second
+	DefaultStreamMaxDeliveries = 5
+	streamDataField            = "data"
+)
+
+func (c *Client) Publish(channel string, value interface{}) (int64, error) {
+	return c.PublishCtx(context.Background(), channel, value)
+}
+
+// PublishCtx encodes value with the client codec and returns the number of
+// subscribers that received it.
+func (c *Client) PublishCtx(ctx context.Context, channel string, value interface{}) (int64, error) {
+	data, err := c.Codec().Encode(value)
+	if err != nil {
+		return 0, err
+	}
+	ctx, cancel := withTimeout(ctx, c.timeout)
+	defer cancel()
+	return c.client.Publish(ctx, c.channel(channel, false), data).Result()
+}
+
+// channel returns the name of channel, or of a pattern of channels, within
+// the client namespace. Channels are plain strings, so they are not escaped
+// like key segments.
+func (c *Client) channel(channel string, pattern bool) string {
+	if len(c.namespace) == 0 {
+		return channel
+	}
+	prefix := c.namespace.String()
+	if pattern {
+		prefix = globReplacer.Replace(prefix)
+	}
+	return prefix + string(KeySeparator) + channel
+}
+
+type Message[T interface{}] struct {
+	Channel string
+	Pattern string
+	Value   T
+}
+
+type SubscribeOptions struct {
+	// Patterns subscribes with PSUBSCRIBE instead of SUBSCRIBE.
+	Patterns   bool
+	RetryDelay time.Duration
+	OnError    func(err error)
+}
+
+// Subscribe calls handler for every message published on channels until ctx
+// is done. Connection failures are retried after RetryDelay, resubscribing
+// to every channel, and reported with OnError like decoding errors.
+func Subscribe[T interface{}](ctx context.Context, c *Client, channels []string, opts SubscribeOptions, handler func(Message[T])) error {
+	if len(channels) == 0 {
+		return fmt.Errorf("no channels to subscribe")
+	}
+	if opts.RetryDelay <= 0 {
+		opts.RetryDelay = DefaultRetryDelay
+	}
+	names := make([]string, len(channels))
+	for i, ch := range channels {
+		names[i] = c.channel(ch, opts.Patterns)
+	}
+	var pubsub *redis.PubSub
+	if opts.Patterns {
+		pubsub = c.client.PSubscribe(ctx, names...)
+	} else {
+		pubsub = c.client.Subscribe(ctx, names...)
+	}
+	defer pubsub.Close()
+	// receiving does not watch the context, closing unblocks it
+	go func() {
+		<-ctx.Done()
+		pubsub.Close()
+	}()
+
+	codec := c.Codec()
+	for {
+		msg, err := pubsub.ReceiveMessage(ctx)
+		if ctx.Err() != nil {
+			return nil
+		}
+		if err != nil {
+			// the next receive reconnects and restores the subscriptions
+			reportError(opts.OnError, err)
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-time.After(opts.RetryDelay):
+			}
+			continue
+		}
+		m := Message[T]{
+			Channel: strings.TrimPrefix(msg.Channel, c.channel("", false)),
+			Pattern: strings.TrimPrefix(msg.Pattern, c.channel("", true)),
+		}
+		if err := codec.Decode([]byte(msg.Payload), &m.Value); err != nil {
+			reportError(opts.OnError, fmt.Errorf("cannot decode message on %v: %w", msg.Channel, err))
+			continue
+		}
+		handler(m)
+	}
+}
+
+func reportError(onError func(err error), err error) {
+	if onError != nil {
+		onError(err)
+	}
+}
+
+// StreamAdd appends value encoded with the client codec to stream, trimming
+// it to about maxLen entries when maxLen is positive.
+func (c *Client) StreamAdd(stream Key, value interface{}, maxLen int64) (string, error) {
+	return c.StreamAddCtx(context.Background(), stream, value, maxLen)
+}
+
+func (c *Client) StreamAddCtx(ctx context.Context, stream Key, value interface{}, maxLen int64) (string, error) {
+	data, err := c.Codec().Encode(value)
+	if err != nil {
+		return "", err
+	}
+	ctx, cancel := withTimeout(ctx, c.timeout)
+	defer cancel()
+	args := &redis.XAddArgs{
+		Stream: c.name(stream),
+		Values: map[string]interface{}{streamDataField: data},
+	}
+	if maxLen > 0 {
+		args.MaxLen = maxLen
+		args.Approx = true
+	}
+	return c.client.XAdd(ctx, args).Result()
+}
+
+type StreamMessage[T interface{}] struct {
+	ID         string
+	Stream     string
+	Value      T
+	Deliveries int64
+}
+
+type ConsumerOptions struct {
+	Group    string
+	Consumer string
+	// BatchSize is the COUNT of every XREADGROUP and XAUTOCLAIM call.
+	BatchSize int64
+	// Block is how long XREADGROUP waits for new messages.
+	Block time.Duration
+	// MinIdle is how long a failed or abandoned message stays pending
+	// before it is claimed again.
+	MinIdle time.Duration
+	// MaxDeliveries moves a message to the dead letter stream once it has
+	// been delivered more times.
+	MaxDeliveries int64
+	// DeadLetter defaults to the stream key followed by "dead".
+	DeadLetter Key
+	OnError    func(err error)
+}
+
+type consumer[T interface{}] struct {
+	client  *Client
+	stream  string
+	dead    string
+	opts    ConsumerOptions
+	handler func(ctx context.Context, msg StreamMessage[T]) error
+}
+
+// Consume reads stream as a member of a consumer group, creating the group
+// if needed, until ctx is done. Messages are acknowledged when handler
+// returns nil, otherwise they stay pending and are delivered again after
+// MinIdle, up to MaxDeliveries times. Messages that cannot be decoded go
+// straight to the dead letter stream.
+func Consume[T interface{}](ctx context.Context, c *Client, stream Key, opts ConsumerOptions, handler func(ctx context.Context, msg StreamMessage[T]) error) error {
+	if opts.Group == "" || opts.Consumer == "" {
+		return fmt.Errorf("empty consumer group or name")
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = DefaultStreamBatchSize
+	}
+	if opts.Block <= 0 {
+		opts.Block = DefaultStreamBlock
+	}
+	if opts.MinIdle <= 0 {
+		opts.MinIdle = DefaultStreamMinIdle
+	}
+	if opts.MaxDeliveries <= 0 {
+		opts.MaxDeliveries = DefaultStreamMaxDeliveries
+	}
+	if len(opts.DeadLetter) == 0 {
+		opts.DeadLetter = stream.Append("dead")
+	}
+	cons := &consumer[T]{
+		client:  c,
+		stream:  c.name(stream),
+		dead:    c.name(opts.DeadLetter),
+		opts:    opts,
+		handler: handler,
+	}
+	if err := cons.createGroup(ctx); err != nil {
+		return err
+	}
+
+	for ctx.Err() == nil {
+		if err := cons.claim(ctx); err != nil && ctx.Err() == nil {
+			reportError(opts.OnError, err)
+		}
+		if err := cons.read(ctx); err != nil && ctx.Err() == nil {
+			reportError(opts.OnError, err)
+			select {
+			case <-ctx.Done():
+			case <-time.After(DefaultRetryDelay):
+			}
+		}
+	}
+	return nil
+}
+
+func (s *consumer[T]) createGroup(ctx context.Context) error {
+	ctx, cancel := withTimeout(ctx, s.client.timeout)
+	defer cancel()
+	err := s.client.client.XGroupCreateMkStream(ctx, s.stream, s.opts.Group, "0").Err()
+	if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		return err
+	}
+	return nil
+}
+
+func (s *consumer[T]) read(ctx context.Context) error {
+	streams, err := s.client.client.XReadGroup(ctx, &redis.XReadGroupArgs{
+		Group:    s.opts.Group,
+		Consumer: s.opts.Consumer,
+		Streams:  []string{s.stream, ">"},
+		Count:    s.opts.BatchSize,
+		Block:    s.opts.Block,
+	}).Result()
+	if err == redis.Nil {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	for _, stream := range streams {
+		for _, msg := range stream.Messages {
+			// unprocessed messages stay pending and are claimed again
+			if ctx.Err() != nil {
+				return nil
+			}
+			s.process(ctx, msg, 1)
+		}
+	}
+	return nil
+}
+
+func (s *consumer[T]) claim(ctx context.Context) error {
+	tctx, cancel := withTimeout(ctx, s.client.timeout)
+	defer cancel()
+	messages, _, err := s.client.client.XAutoClaim(tctx, &redis.XAutoClaimArgs{
+		Stream:   s.stream,
+		Group:    s.opts.Group,
+		Consumer: s.opts.Consumer,
+		MinIdle:  s.opts.MinIdle,
+		Start:    "0-0",
+		Count:    s.opts.BatchSize,
+	}).Result()
+	if err != nil || len(messages) == 0 {
+		return err
+	}
+	// the delivery count of every claimed message is read by ID, since other
+	// pending entries of the group may fall in the same range
+	cmds := make([]*redis.XPendingExtCmd, len(messages))
+	_, err = s.client.client.Pipelined(tctx, func(pipe redis.Pipeliner) error {
+		for i, msg := range messages {
+			cmds[i] = pipe.XPendingExt(tctx, &redis.XPendingExtArgs{
+				Stream: s.stream,
+				Group:  s.opts.Group,
+				Start:  msg.ID,
+				End:    msg.ID,
+				Count:  1,
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	deliveries := make(map[string]int64, len(messages))
+	for _, cmd := range cmds {
+		for _, p := range cmd.Val() {
+			deliveries[p.ID] = p.RetryCount
+		}
+	}
+	for _, msg := range messages {
+		if ctx.Err() != nil {
+			return nil
+		}
+		s.process(ctx, msg, deliveries[msg.ID])
+	}
+	return nil
+}
+
+func (s *consumer[T]) process(ctx context.Context, msg redis.XMessage, deliveries int64) {
+	if deliveries > s.opts.MaxDeliveries {
+		s.deadLetter(msg, deliveries, fmt.Errorf("delivered %v times", deliveries))
+		return
+	}
+	m := StreamMessage[T]{
+		ID:         msg.ID,
+		Stream:     s.stream,
+		Deliveries: deliveries,
+	}
+	data, _ := msg.Values[streamDataField].(string)
+	if err := s.client.Codec().Decode([]byte(data), &m.Value); err != nil {
+		s.deadLetter(msg, deliveries, err)
+		return
+	}
+	if err := s.handler(ctx, m); err != nil {
+		reportError(s.opts.OnError, fmt.Errorf("message %v of %v failed: %w", msg.ID, s.stream, err))
+		return
+	}
+	if err := s.ack(msg.ID); err != nil {
+		reportError(s.opts.OnError, err)
+	}
+}
+
+func (s *consumer[T]) deadLetter(msg redis.XMessage, deliveries int64, cause error) {
+	values := map[string]interface{}{
+		streamDataField: msg.Values[streamDataField],
+		"stream":        s.stream,
+		"id":            msg.ID,
+		"group":         s.opts.Group,
+		"deliveries":    deliveries,
+		"error":         cause.Error(),
+	}
+	tctx, cancel := createContext(s.client.timeout)
+	defer cancel()
+	if err := s.client.client.XAdd(tctx, &redis.XAddArgs{Stream: s.dead, Values: values}).Err(); err != nil {
+		reportError(s.opts.OnError, err)
+		return
+	}
+	if err := s.ack(msg.ID); err != nil {
+		reportError(s.opts.OnError, err)
+	}
+}
+
+// ack ignores the consumer context, so that the message being handled on
+// shutdown is still acknowledged.
+func (s *consumer[T]) ack(id string) error {
+	ctx, cancel := createContext(s.client.timeout)
+	defer cancel()
+	return s.client.client.XAck(ctx, s.stream, s.opts.Group, id).Err()
+}
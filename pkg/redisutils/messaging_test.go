@@ -0,0 +1,213 @@
+package redisutils
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/sandrolain/go-utilities/pkg/testredisutils"
+	"github.com/stretchr/testify/assert"
+)
+
+func publishUntilReceived(t *testing.T, red *Client, channel string, value interface{}) {
+	for i := 0; i < 100; i++ {
+		n, err := red.Publish(channel, value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n > 0 {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("no subscriber")
+}
+
+func TestSubscribe(t *testing.T) {
+	red := newTestClient(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	received := make(chan Message[TestStruct], 1)
+	done := make(chan error)
+	go func() {
+		done <- Subscribe(ctx, red, []string{"testing:*"}, SubscribeOptions{Patterns: true}, func(msg Message[TestStruct]) {
+			received <- msg
+		})
+	}()
+
+	publishUntilReceived(t, red, "testing:channel", &TestStruct{"hello", 1})
+	msg := <-received
+	assert.Equal(t, "testing:channel", msg.Channel)
+	assert.Equal(t, "testing:*", msg.Pattern)
+	assert.Equal(t, TestStruct{"hello", 1}, msg.Value)
+
+	cancel()
+	assert.Nil(t, <-done)
+}
+
+func TestSubscribeNamespace(t *testing.T) {
+	redisMock := testredisutils.NewMockServer(t, TestPassword)
+	connect := func(namespace ...string) *Client {
+		red, err := NewClientWithOptions(redisMock.Addr(), WithCredentials("", TestPassword), WithTimeout(time.Second), WithNamespace(namespace...))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return red
+	}
+	red := connect("app*")
+	other := connect("other")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	received := make(chan Message[string], 2)
+	done := make(chan error)
+	go func() {
+		done <- Subscribe(ctx, red, []string{"events:*"}, SubscribeOptions{Patterns: true}, func(msg Message[string]) {
+			received <- msg
+		})
+	}()
+
+	publishUntilReceived(t, red, "events:created", "hello")
+	msg := <-received
+	assert.Equal(t, "events:created", msg.Channel)
+	assert.Equal(t, "events:*", msg.Pattern)
+	assert.Equal(t, "hello", msg.Value)
+
+	n, err := other.Publish("events:created", "ignored")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(0), n)
+	n, err = connect("appx").Publish("events:created", "ignored")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(0), n)
+
+	cancel()
+	assert.Nil(t, <-done)
+	assert.Empty(t, received)
+}
+
+func TestConsume(t *testing.T) {
+	red := newTestClient(t)
+	stream := Key{"testing", "stream"}
+	for i := 0; i < 3; i++ {
+		if _, err := red.StreamAdd(stream, &TestStruct{"msg", i}, 100); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var mu sync.Mutex
+	handled := []int{}
+	done := make(chan error)
+	go func() {
+		done <- Consume(ctx, red, stream, ConsumerOptions{Group: "group", Consumer: "c1", Block: 50 * time.Millisecond}, func(ctx context.Context, msg StreamMessage[TestStruct]) error {
+			mu.Lock()
+			defer mu.Unlock()
+			handled = append(handled, msg.Value.Bar)
+			if len(handled) == 3 {
+				cancel()
+			}
+			return nil
+		})
+	}()
+	assert.Nil(t, <-done)
+	assert.Equal(t, []int{0, 1, 2}, handled)
+
+	pending, err := red.client.XPending(context.Background(), stream.String(), "group").Result()
+	assert.Nil(t, err)
+	assert.Equal(t, int64(0), pending.Count)
+}
+
+func TestConsumeDeadLetter(t *testing.T) {
+	red := newTestClient(t)
+	stream := Key{"testing", "failing"}
+	if _, err := red.StreamAdd(stream, &TestStruct{"fail", 0}, 0); err != nil {
+		t.Fatal(err)
+	}
+	red.client.XAdd(context.Background(), &redis.XAddArgs{
+		Stream: stream.String(),
+		Values: map[string]interface{}{"data": "not gob"},
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	attempts := 0
+	done := make(chan error)
+	go func() {
+		done <- Consume(ctx, red, stream, ConsumerOptions{
+			Group:         "group",
+			Consumer:      "c1",
+			Block:         20 * time.Millisecond,
+			MinIdle:       10 * time.Millisecond,
+			MaxDeliveries: 2,
+		}, func(ctx context.Context, msg StreamMessage[TestStruct]) error {
+			attempts++
+			return errors.New("failure")
+		})
+	}()
+
+	dead := Key{"testing", "failing", "dead"}
+	for ctx.Err() == nil {
+		n, err := red.client.XLen(context.Background(), dead.String()).Result()
+		assert.Nil(t, err)
+		if n == 2 {
+			break
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	cancel()
+	assert.Nil(t, <-done)
+
+	messages, err := red.client.XRange(context.Background(), dead.String(), "-", "+").Result()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if assert.Len(t, messages, 2) {
+		assert.Equal(t, "testing:failing", messages[0].Values["stream"])
+		assert.Equal(t, "1", messages[0].Values["deliveries"])
+		assert.Equal(t, "3", messages[1].Values["deliveries"])
+	}
+	assert.Equal(t, 2, attempts)
+}
+
+func TestConsumeClaimDeliveries(t *testing.T) {
+	red := newTestClient(t)
+	stream := Key{"testing", "claimed"}
+	ids := []string{}
+	for i := 0; i < 3; i++ {
+		id, err := red.StreamAdd(stream, &TestStruct{"msg", i}, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, id)
+	}
+	ctx := context.Background()
+	deliveries := map[int]int64{}
+	cons := &consumer[TestStruct]{
+		client: red,
+		stream: stream.String(),
+		opts:   ConsumerOptions{Group: "group", Consumer: "c1", BatchSize: 10, MinIdle: 20 * time.Millisecond, MaxDeliveries: 5},
+		handler: func(ctx context.Context, msg StreamMessage[TestStruct]) error {
+			deliveries[msg.Value.Bar] = msg.Deliveries
+			return nil
+		},
+	}
+	if err := cons.createGroup(ctx); err != nil {
+		t.Fatal(err)
+	}
+	err := red.client.XReadGroup(ctx, &redis.XReadGroupArgs{Group: "group", Consumer: "c0", Streams: []string{stream.String(), ">"}, Count: 3}).Err()
+	if err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(30 * time.Millisecond)
+	// the middle message is still being handled by another consumer
+	err = red.client.XClaim(ctx, &redis.XClaimArgs{Stream: stream.String(), Group: "group", Consumer: "c0", Messages: []string{ids[1]}}).Err()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := cons.claim(ctx); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, map[int]int64{0: 2, 2: 2}, deliveries)
+}
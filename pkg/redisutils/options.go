@@ -0,0 +1,153 @@
+package redisutils
+
+import (
+	"crypto/tls"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+const DefaultTimeout = 10 * time.Second
+
+type clientConfig struct {
+	timeout   time.Duration
+	cluster   bool
+	namespace Key
+	options   *redis.UniversalOptions
+}
+
+type ClientOption func(cfg *clientConfig)
+
+func WithTimeout(timeout time.Duration) ClientOption {
+	return func(cfg *clientConfig) {
+		cfg.timeout = timeout
+	}
+}
+
+// WithNamespace prefixes every key and pub/sub channel used by the client
+// with namespace.
+func WithNamespace(namespace ...string) ClientOption {
+	return func(cfg *clientConfig) {
+		cfg.namespace = NewKey(namespace...)
+	}
+}
+
+// WithCredentials sets the ACL username, which may be empty, and password.
+func WithCredentials(username string, password string) ClientOption {
+	return func(cfg *clientConfig) {
+		cfg.options.Username = username
+		cfg.options.Password = password
+	}
+}
+
+func WithDB(db int) ClientOption {
+	return func(cfg *clientConfig) {
+		cfg.options.DB = db
+	}
+}
+
+func WithTLSConfig(config *tls.Config) ClientOption {
+	return func(cfg *clientConfig) {
+		cfg.options.TLSConfig = config
+	}
+}
+
+// WithSentinel connects to the master named masterName, using the client
+// addresses as Sentinel nodes.
+func WithSentinel(masterName string) ClientOption {
+	return func(cfg *clientConfig) {
+		cfg.options.MasterName = masterName
+	}
+}
+
+func WithSentinelCredentials(username string, password string) ClientOption {
+	return func(cfg *clientConfig) {
+		cfg.options.SentinelUsername = username
+		cfg.options.SentinelPassword = password
+	}
+}
+
+// WithCluster uses the client addresses as Cluster seed nodes. It is only
+// needed with a single seed, as several addresses imply a Cluster.
+func WithCluster() ClientOption {
+	return func(cfg *clientConfig) {
+		cfg.cluster = true
+	}
+}
+
+func WithPoolSize(size int) ClientOption {
+	return func(cfg *clientConfig) {
+		cfg.options.PoolSize = size
+	}
+}
+
+func WithMinIdleConns(n int) ClientOption {
+	return func(cfg *clientConfig) {
+		cfg.options.MinIdleConns = n
+	}
+}
+
+func WithIdleTimeout(d time.Duration) ClientOption {
+	return func(cfg *clientConfig) {
+		cfg.options.IdleTimeout = d
+	}
+}
+
+// WithRetries sets how many times failed commands are retried, waiting
+// between minBackoff and maxBackoff. A negative maxRetries disables retries.
+func WithRetries(maxRetries int, minBackoff time.Duration, maxBackoff time.Duration) ClientOption {
+	return func(cfg *clientConfig) {
+		cfg.options.MaxRetries = maxRetries
+		cfg.options.MinRetryBackoff = minBackoff
+		cfg.options.MaxRetryBackoff = maxBackoff
+	}
+}
+
+// WithUniversalOptions gives access to the go-redis options not covered by
+// the other ClientOption functions.
+func WithUniversalOptions(fn func(o *redis.UniversalOptions)) ClientOption {
+	return func(cfg *clientConfig) {
+		fn(cfg.options)
+	}
+}
+
+// parseAddress accepts a redis:// or rediss:// URL, whose settings become the
+// defaults of the other options, or a comma separated list of addresses.
+func parseAddress(address string) (*redis.UniversalOptions, error) {
+	if !strings.Contains(address, "://") {
+		addrs := strings.Split(address, ",")
+		for i, a := range addrs {
+			addrs[i] = strings.TrimSpace(a)
+			if addrs[i] == "" {
+				return nil, fmt.Errorf("empty Redis address in \"%s\"", address)
+			}
+		}
+		return &redis.UniversalOptions{Addrs: addrs}, nil
+	}
+	o, err := redis.ParseURL(address)
+	if err != nil {
+		return nil, err
+	}
+	return &redis.UniversalOptions{
+		Addrs:              []string{o.Addr},
+		DB:                 o.DB,
+		Username:           o.Username,
+		Password:           o.Password,
+		TLSConfig:          o.TLSConfig,
+		MaxRetries:         o.MaxRetries,
+		MinRetryBackoff:    o.MinRetryBackoff,
+		MaxRetryBackoff:    o.MaxRetryBackoff,
+		DialTimeout:        o.DialTimeout,
+		ReadTimeout:        o.ReadTimeout,
+		WriteTimeout:       o.WriteTimeout,
+		PoolFIFO:           o.PoolFIFO,
+		PoolSize:           o.PoolSize,
+		MinIdleConns:       o.MinIdleConns,
+		MaxConnAge:         o.MaxConnAge,
+		PoolTimeout:        o.PoolTimeout,
+		IdleTimeout:        o.IdleTimeout,
+		IdleCheckFrequency: o.IdleCheckFrequency,
+	}, nil
+}
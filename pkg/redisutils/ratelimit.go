@@ -0,0 +1,162 @@
+package redisutils
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+type RateLimitResult struct {
+	Allowed    bool
+	Limit      int64
+	Remaining  int64
+	RetryAfter time.Duration
+	Reset      time.Duration
+}
+
+type Limiter interface {
+	AllowN(ctx context.Context, key Key, n int64) (RateLimitResult, error)
+}
+
+// scriptNow reads the Redis clock in milliseconds, so that every client
+// shares it regardless of the skew between their own clocks. Effects
+// replication is enabled first, as required before writing after TIME on
+// Redis versions older than 5.
+const scriptNow = `
+redis.replicate_commands()
+local time = redis.call("TIME")
+local now = tonumber(time[1]) * 1000 + math.floor(tonumber(time[2]) / 1000)
+`
+
+// slidingWindowScript keeps a sorted set with a member per request scored by
+// its time in milliseconds.
+var slidingWindowScript = redis.NewScript(scriptNow + `
+local window = tonumber(ARGV[1])
+local limit = tonumber(ARGV[2])
+local n = tonumber(ARGV[3])
+redis.call("ZREMRANGEBYSCORE", KEYS[1], "-inf", now - window)
+local count = redis.call("ZCARD", KEYS[1])
+if count + n <= limit then
+	for i = 1, n do
+		redis.call("ZADD", KEYS[1], now, ARGV[4] .. ":" .. i)
+	end
+	redis.call("PEXPIRE", KEYS[1], window)
+	local oldest = redis.call("ZRANGE", KEYS[1], 0, 0, "WITHSCORES")
+	return {1, limit - count - n, 0, tonumber(oldest[2]) + window - now}
+end
+local first = redis.call("ZRANGE", KEYS[1], count + n - limit - 1, count + n - limit - 1, "WITHSCORES")
+local oldest = redis.call("ZRANGE", KEYS[1], 0, 0, "WITHSCORES")
+return {0, limit - count, tonumber(first[2]) + window - now, tonumber(oldest[2]) + window - now}
+`)
+
+type SlidingWindowLimiter struct {
+	client *Client
+	limit  int64
+	window time.Duration
+}
+
+// NewSlidingWindowLimiter allows up to limit requests in any window long
+// interval.
+func NewSlidingWindowLimiter(c *Client, limit int64, window time.Duration) *SlidingWindowLimiter {
+	return &SlidingWindowLimiter{
+		client: c,
+		limit:  limit,
+		window: window,
+	}
+}
+
+func (l *SlidingWindowLimiter) Allow(ctx context.Context, key Key) (RateLimitResult, error) {
+	return l.AllowN(ctx, key, 1)
+}
+
+func (l *SlidingWindowLimiter) AllowN(ctx context.Context, key Key, n int64) (RateLimitResult, error) {
+	if n <= 0 || n > l.limit {
+		return RateLimitResult{}, fmt.Errorf("invalid rate limit cost %v for limit %v", n, l.limit)
+	}
+	member := strconv.FormatInt(time.Now().UnixNano(), 36) + strconv.FormatInt(rand.Int63(), 36)
+	res, err := runLimitScript(ctx, l.client, slidingWindowScript, key,
+		l.window.Milliseconds(), l.limit, n, member)
+	if err != nil {
+		return RateLimitResult{}, err
+	}
+	res.Limit = l.limit
+	return res, nil
+}
+
+// tokenBucketScript stores the available tokens and the last refill time in
+// milliseconds in a hash. The rate is expressed in tokens per millisecond.
+var tokenBucketScript = redis.NewScript(scriptNow + `
+local capacity = tonumber(ARGV[1])
+local rate = tonumber(ARGV[2])
+local n = tonumber(ARGV[3])
+local data = redis.call("HMGET", KEYS[1], "tokens", "ts")
+local tokens = tonumber(data[1]) or capacity
+local ts = tonumber(data[2]) or now
+tokens = math.min(capacity, tokens + math.max(0, now - ts) * rate)
+local allowed = 0
+local retry = 0
+if tokens >= n then
+	tokens = tokens - n
+	allowed = 1
+else
+	retry = math.ceil((n - tokens) / rate)
+end
+redis.call("HMSET", KEYS[1], "tokens", tostring(tokens), "ts", now)
+redis.call("PEXPIRE", KEYS[1], math.ceil(capacity / rate))
+return {allowed, math.floor(tokens), retry, math.ceil((capacity - tokens) / rate)}
+`)
+
+type TokenBucketLimiter struct {
+	client   *Client
+	capacity int64
+	rate     float64
+}
+
+// NewTokenBucketLimiter allows bursts of up to capacity requests, refilling
+// the bucket with rate tokens per second.
+func NewTokenBucketLimiter(c *Client, capacity int64, rate float64) *TokenBucketLimiter {
+	return &TokenBucketLimiter{
+		client:   c,
+		capacity: capacity,
+		rate:     rate,
+	}
+}
+
+func (l *TokenBucketLimiter) Allow(ctx context.Context, key Key) (RateLimitResult, error) {
+	return l.AllowN(ctx, key, 1)
+}
+
+func (l *TokenBucketLimiter) AllowN(ctx context.Context, key Key, n int64) (RateLimitResult, error) {
+	if n <= 0 || n > l.capacity || l.rate <= 0 {
+		return RateLimitResult{}, fmt.Errorf("invalid rate limit cost %v for capacity %v", n, l.capacity)
+	}
+	res, err := runLimitScript(ctx, l.client, tokenBucketScript, key,
+		l.capacity, strconv.FormatFloat(l.rate/1000, 'g', -1, 64), n)
+	if err != nil {
+		return RateLimitResult{}, err
+	}
+	res.Limit = l.capacity
+	return res, nil
+}
+
+func runLimitScript(ctx context.Context, c *Client, script *redis.Script, key Key, args ...interface{}) (RateLimitResult, error) {
+	ctx, cancel := withTimeout(ctx, c.timeout)
+	defer cancel()
+	values, err := script.Run(ctx, c.client, []string{c.name(key)}, args...).Int64Slice()
+	if err != nil {
+		return RateLimitResult{}, err
+	}
+	if len(values) != 4 {
+		return RateLimitResult{}, fmt.Errorf("unexpected rate limit script result %v", values)
+	}
+	return RateLimitResult{
+		Allowed:    values[0] == 1,
+		Remaining:  values[1],
+		RetryAfter: time.Duration(values[2]) * time.Millisecond,
+		Reset:      time.Duration(values[3]) * time.Millisecond,
+	}, nil
+}
@@ -0,0 +1,100 @@
+package redisutils
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/sandrolain/go-utilities/pkg/testredisutils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSlidingWindowLimiter(t *testing.T) {
+	red := newTestClient(t)
+	limiter := NewSlidingWindowLimiter(red, 3, time.Minute)
+	key := Key{"testing", "sliding"}
+	ctx := context.Background()
+
+	for i := int64(2); i >= 0; i-- {
+		res, err := limiter.Allow(ctx, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.True(t, res.Allowed)
+		assert.Equal(t, int64(3), res.Limit)
+		assert.Equal(t, i, res.Remaining)
+	}
+
+	res, err := limiter.Allow(ctx, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.False(t, res.Allowed)
+	assert.Equal(t, int64(0), res.Remaining)
+	assert.True(t, res.RetryAfter > 50*time.Second && res.RetryAfter <= time.Minute)
+
+	other, err := limiter.AllowN(ctx, Key{"testing", "slidingOther"}, 3)
+	assert.Nil(t, err)
+	assert.True(t, other.Allowed)
+
+	_, err = limiter.AllowN(ctx, key, 4)
+	assert.NotNil(t, err)
+}
+
+func TestTokenBucketLimiter(t *testing.T) {
+	red := newTestClient(t)
+	limiter := NewTokenBucketLimiter(red, 2, 10)
+	key := Key{"testing", "bucket"}
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		res, err := limiter.Allow(ctx, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.True(t, res.Allowed)
+	}
+	res, err := limiter.Allow(ctx, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.False(t, res.Allowed)
+	assert.True(t, res.RetryAfter > 0 && res.RetryAfter <= 100*time.Millisecond)
+
+	time.Sleep(150 * time.Millisecond)
+	res, err = limiter.Allow(ctx, key)
+	assert.Nil(t, err)
+	assert.True(t, res.Allowed)
+}
+
+func TestLimitersUseServerClock(t *testing.T) {
+	redisMock := testredisutils.NewMockServer(t, TestPassword)
+	red, err := NewClientWithOptions(redisMock.Addr(), WithCredentials("", TestPassword), WithTimeout(time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	// the server clock is far from the client one and moves on its own
+	start := time.Now().Add(-time.Hour)
+	redisMock.SetTime(start)
+
+	limiters := map[string]Limiter{
+		"sliding": NewSlidingWindowLimiter(red, 2, time.Minute),
+		"bucket":  NewTokenBucketLimiter(red, 2, 1.0/30),
+	}
+	for name, limiter := range limiters {
+		redisMock.SetTime(start)
+		key := Key{"testing", "clock", name}
+		res, err := limiter.AllowN(ctx, key, 2)
+		assert.Nil(t, err)
+		assert.True(t, res.Allowed)
+		res, err = limiter.AllowN(ctx, key, 1)
+		assert.Nil(t, err)
+		assert.False(t, res.Allowed, name)
+
+		redisMock.SetTime(start.Add(61 * time.Second))
+		res, err = limiter.AllowN(ctx, key, 1)
+		assert.Nil(t, err)
+		assert.True(t, res.Allowed, name)
+	}
+}
@@ -1,12 +1,10 @@
 package redisutils
 
 import (
-	"bytes"
 	"context"
 	"crypto/tls"
-	"encoding/gob"
 	"fmt"
-	"strings"
+	"reflect"
 	"time"
 
 	"github.com/go-redis/redis/v8"
@@ -22,103 +20,184 @@ func DefaultAddress() string {
 }
 
 func createContext(timeout time.Duration) (context.Context, context.CancelFunc) {
-	return context.WithTimeout(context.Background(), timeout*time.Second)
+	return withTimeout(context.Background(), timeout)
 }
 
-type Key []string
-
-func (k *Key) String() string {
-	return strings.Join(*k, ":")
+// withTimeout bounds a Redis call made with ctx by the client timeout,
+// keeping the deadline of ctx when it expires first, e.g. for a lock wait
+// shorter than the timeout.
+func withTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	deadline := time.Now().Add(timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		return context.WithCancel(ctx)
+	}
+	return context.WithDeadline(ctx, deadline)
 }
 
 type Client struct {
-	client  *redis.Client
-	timeout time.Duration
+	client    redis.UniversalClient
+	timeout   time.Duration
+	codec     Codec
+	namespace Key
+	loads     *loadGroups
 }
 
+// NewClient connects to a single node, or to the address given as a URL,
+// with the timeout expressed in seconds. An empty password disables
+// authentication.
 func NewClient(address string, password string, tls *tls.Config, timeout time.Duration) (*Client, error) {
+	if timeout == 0 {
+		return nil, fmt.Errorf("empty Redis timeout")
+	}
+	opts := []ClientOption{WithTimeout(timeout * time.Second)}
+	if password != "" {
+		opts = append(opts, WithCredentials("", password))
+	}
+	if tls != nil {
+		opts = append(opts, WithTLSConfig(tls))
+	}
+	return NewClientWithOptions(address, opts...)
+}
+
+// NewClientWithOptions connects to a single node, a Sentinel monitored
+// master or a Cluster. The address is either a redis:// or rediss:// URL or a
+// comma separated list of host:port addresses, see WithSentinel and
+// WithCluster.
+func NewClientWithOptions(address string, opts ...ClientOption) (*Client, error) {
 	if address == "" {
 		return nil, fmt.Errorf("empty Redis address")
 	}
-	if password == "" {
-		return nil, fmt.Errorf("empty Redis password")
+	options, err := parseAddress(address)
+	if err != nil {
+		return nil, err
+	}
+	cfg := &clientConfig{
+		timeout: DefaultTimeout,
+		options: options,
 	}
-	if timeout == 0 {
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	if cfg.timeout <= 0 {
 		return nil, fmt.Errorf("empty Redis timeout")
 	}
 
-	client := redis.NewClient(&redis.Options{
-		Addr:      address,
-		Password:  password,
-		DB:        0, // use default DB
-		TLSConfig: tls,
-	})
+	var client redis.UniversalClient
+	if cfg.cluster && cfg.options.MasterName == "" {
+		client = redis.NewClusterClient(cfg.options.Cluster())
+	} else {
+		client = redis.NewUniversalClient(cfg.options)
+	}
 
-	ctx, cancel := createContext(timeout)
+	ctx, cancel := createContext(cfg.timeout)
 	defer cancel()
 
-	_, err := client.Ping(ctx).Result()
+	_, err = client.Ping(ctx).Result()
 	if err != nil {
+		client.Close()
 		return nil, err
 	}
 
 	res := Client{
-		client:  client,
-		timeout: timeout,
+		client:    client,
+		timeout:   cfg.timeout,
+		codec:     DefaultCodec,
+		namespace: cfg.namespace,
+		loads:     &loadGroups{},
 	}
 	return &res, nil
 }
 
+func (c *Client) Close() error {
+	return c.client.Close()
+}
+
+func (c *Client) Timeout() time.Duration {
+	return c.timeout
+}
+
+func (c *Client) SetCodec(codec Codec) {
+	c.codec = codec
+}
+
+func (c *Client) Codec() Codec {
+	if c.codec == nil {
+		return DefaultCodec
+	}
+	return c.codec
+}
+
+// WithCodec returns a client sharing the same connection that encodes values
+// with codec, e.g. c.WithCodec(JSONCodec).Set(key, value, ttl).
+func (c *Client) WithCodec(codec Codec) *Client {
+	res := *c
+	res.codec = codec
+	return &res
+}
+
 func (c *Client) Set(key Key, value interface{}, ttl time.Duration) error {
-	ctx, cancel := createContext(c.timeout)
+	return c.SetCtx(context.Background(), key, value, ttl)
+}
+
+func (c *Client) SetCtx(ctx context.Context, key Key, value interface{}, ttl time.Duration) error {
+	ctx, cancel := withTimeout(ctx, c.timeout)
 	defer cancel()
-	var gobBuff bytes.Buffer
-	enc := gob.NewEncoder(&gobBuff)
-	err := enc.Encode(value)
+	return c.set(ctx, key, value, ttl)
+}
+
+func (c *Client) set(ctx context.Context, key Key, value interface{}, ttl time.Duration) error {
+	data, err := c.Codec().Encode(value)
 	if err != nil {
 		return err
 	}
-	return c.client.Set(ctx, key.String(), gobBuff.Bytes(), ttl).Err()
+	return c.client.Set(ctx, c.name(key), data, ttl).Err()
 }
 
-func (c *Client) SetNoTtl(key Key, value interface{}, ttl time.Duration) error {
+func (c *Client) SetNoTtl(key Key, value interface{}) error {
 	return c.Set(key, value, 0)
 }
 
 func (c *Client) Get(key Key, value interface{}) (bool, error) {
-	ctx, cancel := createContext(c.timeout)
+	return c.GetCtx(context.Background(), key, value)
+}
+
+func (c *Client) GetCtx(ctx context.Context, key Key, value interface{}) (bool, error) {
+	ctx, cancel := withTimeout(ctx, c.timeout)
 	defer cancel()
-	gobValue, err := c.client.Get(ctx, key.String()).Bytes()
+	return c.get(ctx, key, value)
+}
+
+func (c *Client) get(ctx context.Context, key Key, value interface{}) (bool, error) {
+	data, err := c.client.Get(ctx, c.name(key)).Bytes()
 	if err == redis.Nil {
 		return false, nil
 	}
 	if err != nil {
 		return false, err
 	}
-	var gobBuff bytes.Buffer
-	gobBuff.Write(gobValue)
-	enc := gob.NewDecoder(&gobBuff)
-	return true, enc.Decode(value)
+	return true, c.Codec().Decode(data, value)
 }
 
+// GetAll decodes every value under key into a new instance of the type
+// pointed to by value and returns them ordered by key. ScanAll is the typed
+// alternative.
 func (c *Client) GetAll(key Key, value interface{}) ([]interface{}, error) {
-	ctx, cancel := createContext(c.timeout)
-	defer cancel()
-	key = append(key, "*")
-	iter := c.client.Scan(ctx, 0, key.String(), 0).Iterator()
-	res := make([]interface{}, 0)
-	for iter.Next(ctx) {
-		key := iter.Val()
-		ok, err := c.Get(Key{key}, value)
-		if err != nil {
+	t := reflect.TypeOf(value)
+	if t == nil || t.Kind() != reflect.Ptr {
+		return nil, fmt.Errorf("value must be a pointer")
+	}
+	items, err := ScanSlice[[]byte](context.Background(), c.WithCodec(RawCodec), key, ScanOptions{})
+	if err != nil {
+		return nil, err
+	}
+	codec := c.Codec()
+	res := make([]interface{}, 0, len(items))
+	for _, item := range items {
+		v := reflect.New(t.Elem())
+		if err := codec.Decode(item.Value, v.Interface()); err != nil {
 			return res, err
 		}
-		if ok {
-			value = append(res, value)
-		}
-	}
-	if err := iter.Err(); err != nil {
-		return res, err
+		res = append(res, v.Elem().Interface())
 	}
 	return res, nil
 }
@@ -138,5 +217,5 @@ func AllAsType[T interface{}](all []interface{}) ([]T, error) {
 func (c *Client) Delete(key Key) error {
 	ctx, cancel := createContext(c.timeout)
 	defer cancel()
-	return c.client.Del(ctx, key.String()).Err()
+	return c.client.Del(ctx, c.name(key)).Err()
 }
@@ -0,0 +1,223 @@
+package redisutils
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/go-redis/redis/v8"
+)
+
+const DefaultScanBatchSize = 100
+
+type ScanOptions struct {
+	// Count is the COUNT hint sent with every SCAN call.
+	Count int64
+	// BatchSize is the maximum number of keys fetched with a single MGET.
+	BatchSize int
+}
+
+type ScanItem[T interface{}] struct {
+	Key   string
+	Value T
+}
+
+// ScanIterator walks every key under a prefix one SCAN page at a time, on
+// every master node of a Cluster. SCAN may return a key more than once, so
+// duplicates are possible.
+type ScanIterator[T interface{}] struct {
+	client  *Client
+	pattern string
+	opts    ScanOptions
+	nodes   []redis.Cmdable
+	node    int
+	cursor  uint64
+	items   []ScanItem[T]
+	current ScanItem[T]
+	err     error
+}
+
+func Scan[T interface{}](c *Client, prefix Key, opts ScanOptions) *ScanIterator[T] {
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = DefaultScanBatchSize
+	}
+	return &ScanIterator[T]{
+		client:  c,
+		pattern: c.pattern(prefix),
+		opts:    opts,
+	}
+}
+
+func (it *ScanIterator[T]) Next(ctx context.Context) bool {
+	for len(it.items) == 0 {
+		if it.err != nil {
+			return false
+		}
+		if it.nodes == nil {
+			it.nodes, it.err = it.client.scanNodes(ctx)
+			if it.err != nil {
+				return false
+			}
+		}
+		if it.node >= len(it.nodes) {
+			return false
+		}
+		if err := it.fetch(ctx); err != nil {
+			it.err = err
+			return false
+		}
+	}
+	it.current = it.items[0]
+	it.items = it.items[1:]
+	return true
+}
+
+func (it *ScanIterator[T]) fetch(ctx context.Context) error {
+	tctx, cancel := withTimeout(ctx, it.client.timeout)
+	keys, cursor, err := it.nodes[it.node].Scan(tctx, it.cursor, it.pattern, it.opts.Count).Result()
+	cancel()
+	if err != nil {
+		return err
+	}
+	it.cursor = cursor
+	if cursor == 0 {
+		it.node++
+	}
+	for start := 0; start < len(keys); start += it.opts.BatchSize {
+		end := start + it.opts.BatchSize
+		if end > len(keys) {
+			end = len(keys)
+		}
+		if err := it.load(ctx, keys[start:end]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (it *ScanIterator[T]) load(ctx context.Context, keys []string) error {
+	ctx, cancel := withTimeout(ctx, it.client.timeout)
+	defer cancel()
+	values, err := it.client.getRaw(ctx, keys)
+	if err != nil {
+		return err
+	}
+	codec := it.client.Codec()
+	for i, v := range values {
+		// keys expired or deleted after the SCAN are returned as nil
+		s, ok := v.(string)
+		if !ok {
+			continue
+		}
+		item := ScanItem[T]{Key: it.client.trimNamespace(keys[i])}
+		if err := codec.Decode([]byte(s), &item.Value); err != nil {
+			return err
+		}
+		it.items = append(it.items, item)
+	}
+	return nil
+}
+
+func (c *Client) scanNodes(ctx context.Context) ([]redis.Cmdable, error) {
+	cluster, ok := c.client.(*redis.ClusterClient)
+	if !ok {
+		return []redis.Cmdable{c.client}, nil
+	}
+	ctx, cancel := withTimeout(ctx, c.timeout)
+	defer cancel()
+	var mu sync.Mutex
+	nodes := []redis.Cmdable{}
+	err := cluster.ForEachMaster(ctx, func(ctx context.Context, node *redis.Client) error {
+		mu.Lock()
+		defer mu.Unlock()
+		nodes = append(nodes, node)
+		return nil
+	})
+	return nodes, err
+}
+
+// getRaw returns the values of keys, nil for the missing ones, with a single
+// MGET or, on a Cluster where keys may belong to different slots, with a
+// pipeline of GETs.
+func (c *Client) getRaw(ctx context.Context, keys []string) ([]interface{}, error) {
+	if _, ok := c.client.(*redis.ClusterClient); !ok {
+		return c.client.MGet(ctx, keys...).Result()
+	}
+	cmds := make([]*redis.StringCmd, len(keys))
+	_, err := c.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i, k := range keys {
+			cmds[i] = pipe.Get(ctx, k)
+		}
+		return nil
+	})
+	if err != nil && err != redis.Nil {
+		return nil, err
+	}
+	res := make([]interface{}, len(keys))
+	for i, cmd := range cmds {
+		v, err := cmd.Result()
+		if err == redis.Nil {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		res[i] = v
+	}
+	return res, nil
+}
+
+func (it *ScanIterator[T]) Key() string {
+	return it.current.Key
+}
+
+func (it *ScanIterator[T]) Value() T {
+	return it.current.Value
+}
+
+func (it *ScanIterator[T]) Err() error {
+	return it.err
+}
+
+// ScanAll loads every value stored under prefix, keyed by the key string
+// without the client namespace.
+func ScanAll[T interface{}](c *Client, prefix Key) (map[string]T, error) {
+	return ScanAllCtx[T](context.Background(), c, prefix, ScanOptions{})
+}
+
+func ScanAllCtx[T interface{}](ctx context.Context, c *Client, prefix Key, opts ScanOptions) (map[string]T, error) {
+	it := Scan[T](c, prefix, opts)
+	res := map[string]T{}
+	for it.Next(ctx) {
+		res[it.Key()] = it.Value()
+	}
+	return res, it.Err()
+}
+
+// ScanSlice is like ScanAllCtx but returns the items sorted by key.
+func ScanSlice[T interface{}](ctx context.Context, c *Client, prefix Key, opts ScanOptions) ([]ScanItem[T], error) {
+	all, err := ScanAllCtx[T](ctx, c, prefix, opts)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]ScanItem[T], 0, len(all))
+	for k, v := range all {
+		res = append(res, ScanItem[T]{k, v})
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Key < res[j].Key
+	})
+	return res, nil
+}
+
+var globReplacer = strings.NewReplacer(`\`, `\\`, `*`, `\*`, `?`, `\?`, `[`, `\[`, `]`, `\]`)
+
+// pattern matches every key under prefix within the client namespace.
+func (c *Client) pattern(prefix Key) string {
+	name := c.name(prefix)
+	if name == "" {
+		return "*"
+	}
+	return globReplacer.Replace(name) + ":*"
+}
@@ -0,0 +1,87 @@
+package redisutils
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func fillScanKeys(t *testing.T, red *Client, n int) {
+	for i := 0; i < n; i++ {
+		if err := red.Set(Key{"testing", "scan", fmt.Sprintf("%03d", i)}, &TestStruct{"item", i}, 0); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := red.Set(Key{"testing", "other"}, &TestStruct{"other", 0}, 0); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestScanAll(t *testing.T) {
+	red := newTestClient(t)
+	fillScanKeys(t, red, 25)
+
+	all, err := ScanAll[TestStruct](red, Key{"testing", "scan"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Len(t, all, 25)
+	assert.Equal(t, TestStruct{"item", 7}, all["testing:scan:007"])
+
+	items, err := ScanSlice[TestStruct](context.Background(), red, Key{"testing", "scan"}, ScanOptions{Count: 5, BatchSize: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Len(t, items, 25)
+	for i, item := range items {
+		assert.Equal(t, fmt.Sprintf("testing:scan:%03d", i), item.Key)
+		assert.Equal(t, i, item.Value.Bar)
+	}
+}
+
+func TestScanIterator(t *testing.T) {
+	red := newTestClient(t)
+	fillScanKeys(t, red, 10)
+
+	it := Scan[TestStruct](red, Key{"testing"}, ScanOptions{Count: 2})
+	count := 0
+	for it.Next(context.Background()) {
+		assert.NotEmpty(t, it.Key())
+		count++
+	}
+	assert.Nil(t, it.Err())
+	assert.Equal(t, 11, count)
+
+	empty, err := ScanAll[TestStruct](red, Key{"missing"})
+	assert.Nil(t, err)
+	assert.Len(t, empty, 0)
+}
+
+func TestGetAll(t *testing.T) {
+	red := newTestClient(t)
+	fillScanKeys(t, red, 5)
+
+	all, err := red.GetAll(Key{"testing", "scan"}, &TestStruct{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := AllAsType[TestStruct](all)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Len(t, res, 5)
+	assert.Equal(t, TestStruct{"item", 3}, res[3])
+}
+
+func TestScanPattern(t *testing.T) {
+	red := &Client{}
+	assert.Equal(t, "*", red.pattern(Key{}))
+	assert.Equal(t, `a:b\*:*`, red.pattern(Key{"a", "b*"}))
+	assert.Equal(t, `a:b\\\\\\::*`, red.pattern(Key{"a", `b\:`}))
+
+	red.namespace = Key{"ns"}
+	assert.Equal(t, "ns:*", red.pattern(Key{}))
+	assert.Equal(t, "ns:a:*", red.pattern(Key{"a"}))
+}
@@ -0,0 +1,314 @@
+package redisutils
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// MemberCodec encodes set and sorted set members instead of the client codec,
+// since members are compared by their bytes and must be encoded the same way
+// by every process. Strings and byte slices are stored as they are, numbers
+// and booleans in their text form and other values as JSON, which sorts map
+// keys.
+var MemberCodec Codec = memberCodec{}
+
+type memberCodec struct{}
+
+func (memberCodec) Encode(value interface{}) ([]byte, error) {
+	v := reflect.ValueOf(value)
+	for v.Kind() == reflect.Pointer && !v.IsNil() {
+		v = v.Elem()
+	}
+	switch v.Kind() {
+	case reflect.String:
+		return []byte(v.String()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.AppendInt(nil, v.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.AppendUint(nil, v.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.AppendFloat(nil, v.Float(), 'g', -1, v.Type().Bits()), nil
+	case reflect.Bool:
+		return strconv.AppendBool(nil, v.Bool()), nil
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return append([]byte{}, v.Bytes()...), nil
+		}
+	}
+	return json.Marshal(value)
+}
+
+func (memberCodec) Decode(data []byte, value interface{}) error {
+	p := reflect.ValueOf(value)
+	if p.Kind() != reflect.Pointer || p.IsNil() {
+		return fmt.Errorf("member codec cannot decode into %T", value)
+	}
+	v := p.Elem()
+	s := string(data)
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+		return nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(s, 10, v.Type().Bits())
+		v.SetInt(i)
+		return err
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		u, err := strconv.ParseUint(s, 10, v.Type().Bits())
+		v.SetUint(u)
+		return err
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(s, v.Type().Bits())
+		v.SetFloat(f)
+		return err
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		v.SetBool(b)
+		return err
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			v.SetBytes(append([]byte{}, data...))
+			return nil
+		}
+	}
+	return json.Unmarshal(data, value)
+}
+
+func (c *Client) SAdd(key Key, members ...interface{}) (int64, error) {
+	data, err := encodeAll(MemberCodec, members)
+	if err != nil {
+		return 0, err
+	}
+	ctx, cancel := createContext(c.timeout)
+	defer cancel()
+	return c.client.SAdd(ctx, c.name(key), data...).Result()
+}
+
+func (c *Client) SRem(key Key, members ...interface{}) (int64, error) {
+	data, err := encodeAll(MemberCodec, members)
+	if err != nil {
+		return 0, err
+	}
+	ctx, cancel := createContext(c.timeout)
+	defer cancel()
+	return c.client.SRem(ctx, c.name(key), data...).Result()
+}
+
+func (c *Client) SIsMember(key Key, member interface{}) (bool, error) {
+	data, err := MemberCodec.Encode(member)
+	if err != nil {
+		return false, err
+	}
+	ctx, cancel := createContext(c.timeout)
+	defer cancel()
+	return c.client.SIsMember(ctx, c.name(key), data).Result()
+}
+
+func (c *Client) SCard(key Key) (int64, error) {
+	ctx, cancel := createContext(c.timeout)
+	defer cancel()
+	return c.client.SCard(ctx, c.name(key)).Result()
+}
+
+func SMembers[T interface{}](c *Client, key Key) ([]T, error) {
+	ctx, cancel := createContext(c.timeout)
+	defer cancel()
+	values, err := c.client.SMembers(ctx, c.name(key)).Result()
+	if err != nil {
+		return nil, err
+	}
+	return decodeAll[T](MemberCodec, values)
+}
+
+type ScoredValue[T interface{}] struct {
+	Value T
+	Score float64
+	// Rank is the zero based position in the requested order.
+	Rank int64
+}
+
+func (c *Client) ZAdd(key Key, score float64, member interface{}) error {
+	data, err := MemberCodec.Encode(member)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := createContext(c.timeout)
+	defer cancel()
+	return c.client.ZAdd(ctx, c.name(key), &redis.Z{Score: score, Member: data}).Err()
+}
+
+func (c *Client) ZIncrBy(key Key, incr float64, member interface{}) (float64, error) {
+	data, err := MemberCodec.Encode(member)
+	if err != nil {
+		return 0, err
+	}
+	ctx, cancel := createContext(c.timeout)
+	defer cancel()
+	return c.client.ZIncrBy(ctx, c.name(key), incr, string(data)).Result()
+}
+
+func (c *Client) ZRem(key Key, members ...interface{}) (int64, error) {
+	data, err := encodeAll(MemberCodec, members)
+	if err != nil {
+		return 0, err
+	}
+	ctx, cancel := createContext(c.timeout)
+	defer cancel()
+	return c.client.ZRem(ctx, c.name(key), data...).Result()
+}
+
+func (c *Client) ZScore(key Key, member interface{}) (float64, bool, error) {
+	data, err := MemberCodec.Encode(member)
+	if err != nil {
+		return 0, false, err
+	}
+	ctx, cancel := createContext(c.timeout)
+	defer cancel()
+	score, err := c.client.ZScore(ctx, c.name(key), string(data)).Result()
+	if err == redis.Nil {
+		return 0, false, nil
+	}
+	return score, err == nil, err
+}
+
+// ZRank returns the position of member by ascending score, or by descending
+// score when reverse is set as in a leaderboard.
+func (c *Client) ZRank(key Key, member interface{}, reverse bool) (int64, bool, error) {
+	data, err := MemberCodec.Encode(member)
+	if err != nil {
+		return 0, false, err
+	}
+	ctx, cancel := createContext(c.timeout)
+	defer cancel()
+	var rank int64
+	if reverse {
+		rank, err = c.client.ZRevRank(ctx, c.name(key), string(data)).Result()
+	} else {
+		rank, err = c.client.ZRank(ctx, c.name(key), string(data)).Result()
+	}
+	if err == redis.Nil {
+		return 0, false, nil
+	}
+	return rank, err == nil, err
+}
+
+func (c *Client) ZCard(key Key) (int64, error) {
+	ctx, cancel := createContext(c.timeout)
+	defer cancel()
+	return c.client.ZCard(ctx, c.name(key)).Result()
+}
+
+// ZRange returns the members between the start and stop ranks, both
+// inclusive, by ascending score or descending when reverse is set. Negative
+// ranks count from the end as in Redis.
+func ZRange[T interface{}](c *Client, key Key, start int64, stop int64, reverse bool) ([]ScoredValue[T], error) {
+	ctx, cancel := createContext(c.timeout)
+	defer cancel()
+	name := c.name(key)
+	var values *redis.ZSliceCmd
+	var card *redis.IntCmd
+	// the cardinality resolves the rank of negative starts and is read in
+	// the same transaction as the range
+	_, err := c.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		if start < 0 {
+			card = pipe.ZCard(ctx, name)
+		}
+		if reverse {
+			values = pipe.ZRevRangeWithScores(ctx, name, start, stop)
+		} else {
+			values = pipe.ZRangeWithScores(ctx, name, start, stop)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if card != nil {
+		start += card.Val()
+		if start < 0 {
+			start = 0
+		}
+	}
+	return decodeScored[T](values.Val(), start)
+}
+
+// ZRangeByScore returns the members with min <= score <= max, skipping offset
+// members and returning at most count of them when count is positive.
+// Infinite bounds are supported.
+func ZRangeByScore[T interface{}](c *Client, key Key, min float64, max float64, offset int64, count int64, reverse bool) ([]ScoredValue[T], error) {
+	ctx, cancel := createContext(c.timeout)
+	defer cancel()
+	name := c.name(key)
+	by := &redis.ZRangeBy{
+		Min:    formatScore(min),
+		Max:    formatScore(max),
+		Offset: offset,
+		Count:  count,
+	}
+	if count <= 0 {
+		by.Count = -1
+	}
+	var values *redis.ZSliceCmd
+	var before *redis.IntCmd
+	// ranks are relative to the whole sorted set: the first member follows
+	// every member outside the range on its side, counted in the same
+	// transaction so that ties on the bound are ranked correctly
+	_, err := c.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		if reverse {
+			if !math.IsInf(max, 1) {
+				before = pipe.ZCount(ctx, name, "("+formatScore(max), "+inf")
+			}
+			values = pipe.ZRevRangeByScoreWithScores(ctx, name, by)
+		} else {
+			if !math.IsInf(min, -1) {
+				before = pipe.ZCount(ctx, name, "-inf", "("+formatScore(min))
+			}
+			values = pipe.ZRangeByScoreWithScores(ctx, name, by)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	rank := offset
+	if before != nil {
+		rank += before.Val()
+	}
+	return decodeScored[T](values.Val(), rank)
+}
+
+// Top returns the n members with the highest scores, best first.
+func Top[T interface{}](c *Client, key Key, n int64) ([]ScoredValue[T], error) {
+	if n <= 0 {
+		return []ScoredValue[T]{}, nil
+	}
+	return ZRange[T](c, key, 0, n-1, true)
+}
+
+func decodeScored[T interface{}](values []redis.Z, rank int64) ([]ScoredValue[T], error) {
+	res := make([]ScoredValue[T], len(values))
+	for i, v := range values {
+		s, _ := v.Member.(string)
+		if err := MemberCodec.Decode([]byte(s), &res[i].Value); err != nil {
+			return nil, err
+		}
+		res[i].Score = v.Score
+		res[i].Rank = rank + int64(i)
+	}
+	return res, nil
+}
+
+func formatScore(score float64) string {
+	switch {
+	case math.IsInf(score, 1):
+		return "+inf"
+	case math.IsInf(score, -1):
+		return "-inf"
+	}
+	return strconv.FormatFloat(score, 'g', -1, 64)
+}
@@ -0,0 +1,160 @@
+package redisutils
+
+import (
+	"math"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSet(t *testing.T) {
+	red := newTestClient(t)
+	key := Key{"testing", "set"}
+
+	n, err := red.SAdd(key, "a", "b", "c", "a")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(3), n)
+
+	ok, err := red.SIsMember(key, "b")
+	assert.Nil(t, err)
+	assert.True(t, ok)
+
+	n, err = red.SRem(key, "b")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), n)
+
+	members, err := SMembers[string](red, key)
+	assert.Nil(t, err)
+	sort.Strings(members)
+	assert.Equal(t, []string{"a", "c"}, members)
+
+	n, err = red.SCard(key)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), n)
+}
+
+func TestSortedSet(t *testing.T) {
+	red := newTestClient(t)
+	key := Key{"testing", "leaderboard"}
+
+	assert.Nil(t, red.ZAdd(key, 10, "alice"))
+	assert.Nil(t, red.ZAdd(key, 30, "bob"))
+	assert.Nil(t, red.ZAdd(key, 20, "carol"))
+	score, err := red.ZIncrBy(key, 15, "alice")
+	assert.Nil(t, err)
+	assert.Equal(t, float64(25), score)
+
+	top, err := Top[string](red, key, 2)
+	assert.Nil(t, err)
+	assert.Equal(t, []ScoredValue[string]{{"bob", 30, 0}, {"alice", 25, 1}}, top)
+
+	rank, ok, err := red.ZRank(key, "carol", true)
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, int64(2), rank)
+
+	_, ok, err = red.ZRank(key, "dave", true)
+	assert.Nil(t, err)
+	assert.False(t, ok)
+
+	score, ok, err = red.ZScore(key, "carol")
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, float64(20), score)
+
+	byScore, err := ZRangeByScore[string](red, key, 21, math.Inf(1), 0, 0, false)
+	assert.Nil(t, err)
+	assert.Equal(t, []ScoredValue[string]{{"alice", 25, 1}, {"bob", 30, 2}}, byScore)
+
+	last, err := ZRange[string](red, key, -1, -1, false)
+	assert.Nil(t, err)
+	assert.Equal(t, []ScoredValue[string]{{"bob", 30, 2}}, last)
+
+	n, err := red.ZRem(key, "bob")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), n)
+	n, err = red.ZCard(key)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), n)
+}
+
+func TestMemberCodec(t *testing.T) {
+	type named string
+	values := []interface{}{"a", named("b"), []byte("c"), 12, int8(-3), uint16(7), 1.5, true}
+	expected := []string{"a", "b", "c", "12", "-3", "7", "1.5", "true"}
+	for i, v := range values {
+		data, err := MemberCodec.Encode(v)
+		assert.Nil(t, err)
+		assert.Equal(t, expected[i], string(data))
+	}
+
+	// map keys are sorted, so that the encoding does not depend on the
+	// iteration order
+	for i := 0; i < 10; i++ {
+		data, err := MemberCodec.Encode(map[string]int{"z": 1, "a": 2, "m": 3})
+		assert.Nil(t, err)
+		assert.Equal(t, `{"a":2,"m":3,"z":1}`, string(data))
+	}
+
+	var n int
+	assert.Nil(t, MemberCodec.Decode([]byte("12"), &n))
+	assert.Equal(t, 12, n)
+	var s named
+	assert.Nil(t, MemberCodec.Decode([]byte("b"), &s))
+	assert.Equal(t, named("b"), s)
+	var st TestStruct
+	assert.Nil(t, MemberCodec.Decode([]byte(`{"Foo":"x","Bar":1}`), &st))
+	assert.Equal(t, TestStruct{"x", 1}, st)
+	assert.NotNil(t, MemberCodec.Decode([]byte("x"), &n))
+	assert.NotNil(t, MemberCodec.Decode([]byte("x"), n))
+}
+
+func TestSetMembersIgnoreClientCodec(t *testing.T) {
+	red := newTestClient(t)
+	key := Key{"testing", "set", "codec"}
+
+	member := map[string]int{"a": 1, "b": 2, "c": 3}
+	_, err := red.SAdd(key, member)
+	assert.Nil(t, err)
+	ok, err := red.WithCodec(JSONCodec).SIsMember(key, map[string]int{"c": 3, "b": 2, "a": 1})
+	assert.Nil(t, err)
+	assert.True(t, ok)
+
+	members, err := SMembers[map[string]int](red, key)
+	assert.Nil(t, err)
+	assert.Equal(t, []map[string]int{member}, members)
+}
+
+func TestSortedSetRanks(t *testing.T) {
+	red := newTestClient(t)
+	key := Key{"testing", "ranks"}
+
+	for i, member := range []string{"a", "b", "c", "d", "e"} {
+		assert.Nil(t, red.ZAdd(key, float64(i/2), member))
+	}
+
+	byScore, err := ZRangeByScore[string](red, key, 1, 1, 0, 0, false)
+	assert.Nil(t, err)
+	assert.Equal(t, []ScoredValue[string]{{"c", 1, 2}, {"d", 1, 3}}, byScore)
+
+	byScore, err = ZRangeByScore[string](red, key, 1, 1, 1, 1, false)
+	assert.Nil(t, err)
+	assert.Equal(t, []ScoredValue[string]{{"d", 1, 3}}, byScore)
+
+	byScore, err = ZRangeByScore[string](red, key, 0, 1, 0, 0, true)
+	assert.Nil(t, err)
+	assert.Equal(t, []ScoredValue[string]{{"d", 1, 1}, {"c", 1, 2}, {"b", 0, 3}, {"a", 0, 4}}, byScore)
+
+	byScore, err = ZRangeByScore[string](red, key, math.Inf(-1), 0, 0, 0, false)
+	assert.Nil(t, err)
+	assert.Equal(t, []ScoredValue[string]{{"a", 0, 0}, {"b", 0, 1}}, byScore)
+
+	last, err := ZRange[string](red, key, -2, -1, true)
+	assert.Nil(t, err)
+	assert.Equal(t, []ScoredValue[string]{{"b", 0, 3}, {"a", 0, 4}}, last)
+
+	all, err := ZRange[string](red, key, -10, 0, false)
+	assert.Nil(t, err)
+	assert.Equal(t, []ScoredValue[string]{{"a", 0, 0}}, all)
+}